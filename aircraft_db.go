@@ -0,0 +1,336 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	_ "modernc.org/sqlite"
+)
+
+// One aircraft from a registration database
+type AircraftRecord struct {
+	ICAOHex       string
+	Registration  string
+	Type          string
+	Manufacturer  string
+	Model         string
+	OwnerOperator string
+	Year          string
+	Military      bool
+}
+
+// An offline registration database, indexed by normalized registration and
+// ICAO hex
+type AircraftDatabase struct {
+	mu             sync.RWMutex
+	path           string
+	modTime        time.Time
+	byRegistration map[string]AircraftRecord
+	byHex          map[string]AircraftRecord
+}
+
+var aircraftDB *AircraftDatabase
+
+// Column names we understand in a CSV with a header or the BaseStation
+// Aircraft table, lowercased
+var aircraftDBColumns = map[string]string{
+	"modes":            "hex",
+	"icao":             "hex",
+	"icao24":           "hex",
+	"hex":              "hex",
+	"registration":     "registration",
+	"reg":              "registration",
+	"icaotypecode":     "type",
+	"typecode":         "type",
+	"manufacturer":     "manufacturer",
+	"type":             "model",
+	"model":            "model",
+	"registeredowners": "owner",
+	"owner":            "owner",
+	"operator":         "owner",
+	"ownop":            "owner",
+	"yearbuilt":        "year",
+	"year":             "year",
+	"military":         "military",
+	"mil":              "military",
+}
+
+// Loads the database at path and keeps it up to date, checking for changes
+// every reloadInterval
+func ConfigureAircraftDatabase() {
+	if config.AircraftDBFile == "" {
+		return
+	}
+	db := &AircraftDatabase{path: config.AircraftDBFile}
+	if err := db.Reload(); err != nil {
+		log.Fatalf("error loading aircraft database: %s", err)
+	}
+	aircraftDB = db
+	reloadSeconds := config.AircraftDBReloadSeconds
+	if reloadSeconds == 0 {
+		reloadSeconds = 300
+	}
+	if reloadSeconds > 0 {
+		go db.ReloadEvery(time.Duration(reloadSeconds) * time.Second)
+	}
+}
+
+// Re-reads the file if it changed since it was last loaded
+func (db *AircraftDatabase) Reload() error {
+	info, err := os.Stat(db.path)
+	if err != nil {
+		return err
+	}
+	db.mu.RLock()
+	unchanged := info.ModTime().Equal(db.modTime)
+	db.mu.RUnlock()
+	if unchanged {
+		return nil
+	}
+
+	byRegistration, byHex, err := LoadAircraftDatabase(db.path)
+	if err != nil {
+		return err
+	}
+	db.mu.Lock()
+	db.byRegistration, db.byHex, db.modTime = byRegistration, byHex, info.ModTime()
+	db.mu.Unlock()
+	log.Infof("loaded %d aircraft from %s", len(byHex), db.path)
+	return nil
+}
+
+func (db *AircraftDatabase) ReloadEvery(interval time.Duration) {
+	for range time.Tick(interval) {
+		if err := db.Reload(); err != nil {
+			log.Errorf("error reloading aircraft database: %s", err)
+		}
+	}
+}
+
+func (db *AircraftDatabase) LookupRegistration(reg string) (AircraftRecord, bool) {
+	if db == nil {
+		return AircraftRecord{}, false
+	}
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	record, ok := db.byRegistration[NormalizeAircraftRegistration(reg)]
+	return record, ok
+}
+
+func (db *AircraftDatabase) LookupHex(hex string) (AircraftRecord, bool) {
+	if db == nil {
+		return AircraftRecord{}, false
+	}
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	record, ok := db.byHex[strings.ToLower(strings.TrimSpace(hex))]
+	return record, ok
+}
+
+// Reads a tar1090-db aircraft.csv (semicolon separated, no header,
+// optionally gzipped), a comma separated file with a header row or a
+// BaseStation.sqb
+func LoadAircraftDatabase(path string) (byRegistration, byHex map[string]AircraftRecord, err error) {
+	var records []AircraftRecord
+	if strings.HasSuffix(path, ".sqb") || strings.HasSuffix(path, ".sqlite") {
+		records, err = readBaseStationDB(path)
+	} else {
+		records, err = readAircraftDBFile(path)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	byRegistration = make(map[string]AircraftRecord, len(records))
+	byHex = make(map[string]AircraftRecord, len(records))
+	for _, record := range records {
+		if record.ICAOHex != "" {
+			byHex[record.ICAOHex] = record
+		}
+		if record.Registration != "" {
+			byRegistration[NormalizeAircraftRegistration(record.Registration)] = record
+		}
+	}
+	return byRegistration, byHex, nil
+}
+
+func readAircraftDBFile(path string) (records []AircraftRecord, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var reader io.Reader = file
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		reader = gz
+	}
+	buffered := bufio.NewReader(reader)
+	firstLine, err := buffered.Peek(512)
+	if err != nil && err != io.EOF && !errors.Is(err, bufio.ErrBufferFull) {
+		return nil, err
+	}
+	lineEnd := strings.IndexByte(string(firstLine), '\n')
+	if lineEnd < 0 {
+		lineEnd = len(firstLine)
+	}
+
+	if strings.Contains(string(firstLine[:lineEnd]), ";") {
+		return readTar1090DB(buffered)
+	}
+	return readHeaderCSV(buffered)
+}
+
+// Reads the Aircraft table of a BaseStation.sqb
+func readBaseStationDB(path string) (records []AircraftRecord, err error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?mode=ro")
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	rows, err := db.Query("SELECT * FROM Aircraft")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	header, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	columns := aircraftDBColumnIndexes(header)
+	values := make([]any, len(header))
+	pointers := make([]any, len(header))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			return records, err
+		}
+		row := make([]string, len(values))
+		for i, value := range values {
+			switch v := value.(type) {
+			case nil:
+			case []byte:
+				row[i] = string(v)
+			default:
+				row[i] = fmt.Sprint(v)
+			}
+		}
+		records = append(records, aircraftRecordFromRow(columns, row))
+	}
+	return records, rows.Err()
+}
+
+// ex: "a1b2c3;N12345;B738;00;BOEING 737-800;2015;UNITED AIRLINES INC;"
+func readTar1090DB(reader io.Reader) (records []AircraftRecord, err error) {
+	r := csv.NewReader(reader)
+	r.Comma = ';'
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	for {
+		row, err := r.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return records, err
+		}
+		if len(row) < 2 {
+			continue
+		}
+		field := func(i int) string {
+			if i < len(row) {
+				return strings.TrimSpace(row[i])
+			}
+			return ""
+		}
+		// Descriptions lead with the manufacturer, ex: "AIRBUS A-320"
+		manufacturer, model, _ := strings.Cut(field(4), " ")
+		records = append(records, AircraftRecord{
+			ICAOHex:       strings.ToLower(field(0)),
+			Registration:  field(1),
+			Type:          field(2),
+			Manufacturer:  manufacturer,
+			Model:         model,
+			OwnerOperator: field(6),
+			Year:          field(5),
+			// Flags are a string of 0s and 1s, the first is military
+			Military: strings.HasPrefix(field(3), "1"),
+		})
+	}
+}
+
+func readHeaderCSV(reader io.Reader) (records []AircraftRecord, err error) {
+	r := csv.NewReader(reader)
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	header, err := r.Read()
+	if err != nil {
+		return nil, err
+	}
+	columns := aircraftDBColumnIndexes(header)
+	if _, ok := columns["hex"]; !ok {
+		if _, ok := columns["registration"]; !ok {
+			return nil, errors.New("aircraft database has neither a hex nor a registration column")
+		}
+	}
+	for {
+		row, err := r.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return records, err
+		}
+		records = append(records, aircraftRecordFromRow(columns, row))
+	}
+}
+
+// Maps our column names to their position in the header
+func aircraftDBColumnIndexes(header []string) map[string]int {
+	columns := map[string]int{}
+	for i, name := range header {
+		if column, ok := aircraftDBColumns[strings.ToLower(strings.TrimSpace(name))]; ok {
+			if _, seen := columns[column]; !seen {
+				columns[column] = i
+			}
+		}
+	}
+	return columns
+}
+
+func aircraftRecordFromRow(columns map[string]int, row []string) AircraftRecord {
+	field := func(column string) string {
+		if i, ok := columns[column]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+	military := strings.ToLower(field("military"))
+	return AircraftRecord{
+		ICAOHex:       strings.ToLower(field("hex")),
+		Registration:  field("registration"),
+		Type:          field("type"),
+		Manufacturer:  field("manufacturer"),
+		Model:         field("model"),
+		OwnerOperator: field("owner"),
+		Year:          field("year"),
+		Military:      military == "1" || military == "true" || military == "yes",
+	}
+}
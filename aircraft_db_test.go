@@ -0,0 +1,101 @@
+package main
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadTar1090DBFlags(t *testing.T) {
+	tests := []struct {
+		flags    string
+		military bool
+	}{
+		{"00", false},
+		{"10", true},
+		{"01", false},
+		{"11", true},
+		{"", false},
+	}
+	for _, test := range tests {
+		line := "ae1234;12-3456;C17;" + test.flags + ";BOEING C-17A;;;\n"
+		records, err := readTar1090DB(strings.NewReader(line))
+		if err != nil {
+			t.Fatalf("flags %q: %s", test.flags, err)
+		}
+		if len(records) != 1 || records[0].Military != test.military {
+			t.Errorf("flags %q: got %+v, want military %t", test.flags, records, test.military)
+		}
+	}
+}
+
+func TestReadTar1090DBFields(t *testing.T) {
+	records, err := readTar1090DB(strings.NewReader("A1B2C3;N12345;B738;00;BOEING 737-800;2015;UNITED AIRLINES INC;\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := AircraftRecord{
+		ICAOHex:       "a1b2c3",
+		Registration:  "N12345",
+		Type:          "B738",
+		Manufacturer:  "BOEING",
+		Model:         "737-800",
+		OwnerOperator: "UNITED AIRLINES INC",
+		Year:          "2015",
+	}
+	if len(records) != 1 || records[0] != want {
+		t.Errorf("got %+v, want %+v", records, want)
+	}
+}
+
+func TestReadHeaderCSV(t *testing.T) {
+	csv := "ModeS,Registration,ICAOTypeCode,Manufacturer,Type,RegisteredOwners,Military\n" +
+		"40621D,G-EZWX,A320,Airbus,A320-214,easyJet Airline Company Ltd,0\n"
+	records, err := readHeaderCSV(strings.NewReader(csv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].ICAOHex != "40621d" || records[0].Model != "A320-214" || records[0].Military {
+		t.Errorf("got %+v", records)
+	}
+	if _, err := readHeaderCSV(strings.NewReader("foo,bar\n1,2\n")); err == nil {
+		t.Error("expected an error without a hex or registration column")
+	}
+}
+
+func TestLoadAircraftDatabaseBaseStation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "BaseStation.sqb")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, statement := range []string{
+		"CREATE TABLE Aircraft (AircraftID INTEGER PRIMARY KEY, ModeS VARCHAR(6), Registration VARCHAR(20), ICAOTypeCode VARCHAR(10), YearBuilt VARCHAR(4), RegisteredOwners VARCHAR(100))",
+		"INSERT INTO Aircraft (ModeS, Registration, ICAOTypeCode, YearBuilt, RegisteredOwners) VALUES ('A1B2C3', 'N12345', 'B738', NULL, 'United Airlines')",
+	} {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db.Close()
+
+	byRegistration, byHex, err := LoadAircraftDatabase(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	record, ok := byHex["a1b2c3"]
+	if !ok || record.Registration != "N12345" || record.Type != "B738" || record.Year != "" {
+		t.Errorf("got %+v", record)
+	}
+	if _, ok := byRegistration[NormalizeAircraftRegistration("N12345")]; !ok {
+		t.Error("registration not indexed")
+	}
+}
+
+func TestLoadAircraftDatabaseMissing(t *testing.T) {
+	if _, _, err := LoadAircraftDatabase(filepath.Join(os.TempDir(), "does-not-exist.csv")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
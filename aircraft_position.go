@@ -0,0 +1,123 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// What a message says about the aircraft that sent or received it
+type MessageAircraft struct {
+	Hex          string
+	Registration string
+	Flight       string
+	Label        string
+	Text         string
+}
+
+// ACARS messages don't carry the hex, so it comes from the aircraft
+// database if the registration is in it
+func ACARSMessageAircraft(m ACARSMessage) MessageAircraft {
+	record, _ := aircraftDB.LookupRegistration(m.AircraftTailCode)
+	return MessageAircraft{
+		Hex:          record.ICAOHex,
+		Registration: m.AircraftTailCode,
+		Flight:       m.FlightNumber,
+		Label:        m.Label,
+		Text:         m.MessageText,
+	}
+}
+
+// VDL2 carries the hex as the AVLC address when the aircraft sent it
+func VDLM2MessageAircraft(m VDLM2Message) MessageAircraft {
+	var hex string
+	if m.VDL2.AVLC.Source.Type == "Aircraft" {
+		hex = m.VDL2.AVLC.Source.Address
+	}
+	acars := m.VDL2.AVLC.ACARS
+	return MessageAircraft{
+		Hex:          hex,
+		Registration: acars.Registration,
+		Flight:       acars.FlightNumber,
+		Label:        acars.Label,
+		Text:         acars.MessageText,
+	}
+}
+
+// Where an aircraft is according to tar1090 or the SBS/readsb stream, and
+// according to the message text
+type AircraftPosition struct {
+	Live       TJSONAircraft
+	HasLive    bool
+	Message    TrackPoint
+	HasMessage bool
+}
+
+// Every annotator that wants a position asks about the same message one
+// after another, so the last answer is kept briefly instead of looking it
+// up and parsing the text each time
+const aircraftPositionReuseWindow = time.Second
+
+var lastAircraftPosition struct {
+	mu       sync.Mutex
+	aircraft MessageAircraft
+	at       time.Time
+	position AircraftPosition
+}
+
+// Looks up where the aircraft is
+func (m MessageAircraft) Position() AircraftPosition {
+	lastAircraftPosition.mu.Lock()
+	if lastAircraftPosition.aircraft == m && time.Since(lastAircraftPosition.at) < aircraftPositionReuseWindow {
+		position := lastAircraftPosition.position
+		lastAircraftPosition.mu.Unlock()
+		return position
+	}
+	lastAircraftPosition.mu.Unlock()
+
+	// Not held while looking up, which can mean a fetch from tar1090, so
+	// other messages aren't held up behind it
+	var position AircraftPosition
+	if AircraftPositionsEnabled() {
+		aircraft, _, err := Tar1090Handler{}.FindAircraft(m.Hex, m.Registration, m.Flight)
+		if err == nil {
+			position.Live, position.HasLive = aircraft, true
+		} else {
+			log.Debugf("no live position for aircraft: %v", err)
+		}
+	}
+	position.Message, position.HasMessage = MessageTrackPoint(m.Label, m.Text)
+	lastAircraftPosition.mu.Lock()
+	lastAircraftPosition.aircraft, lastAircraftPosition.at, lastAircraftPosition.position = m, time.Now(), position
+	lastAircraftPosition.mu.Unlock()
+	return position
+}
+
+// The best single position: live if there is one, otherwise from the text
+func (p AircraftPosition) Point() (point TrackPoint, source string, found bool) {
+	if p.HasLive && (p.Live.Latitude != 0 || p.Live.Longitude != 0) {
+		altitude := p.Live.AltimeterBarometer
+		point = TrackPoint{
+			Latitude:      p.Live.Latitude,
+			Longitude:     p.Live.Longitude,
+			AltitudeFeet:  altitude.Feet,
+			HasAltitude:   altitude.Known && !altitude.OnGround,
+			OnGround:      altitude.OnGround,
+			ReportsGround: altitude.Known,
+		}
+		// Geometric altitude is height above the ellipsoid, which is what
+		// the elevation angle wants. Barometric is pressure altitude,
+		// roughly above sea level, so it's only a fallback. alt_geom is
+		// left out when unknown.
+		if p.Live.AltimeterGeometricFeet != 0 && !point.OnGround {
+			point.AltitudeFeet = int64(p.Live.AltimeterGeometricFeet)
+			point.HasAltitude = true
+		}
+		return point, p.Live.PositionSource(), true
+	}
+	if p.HasMessage {
+		return p.Message, PositionSourceMessage, true
+	}
+	return point, "", false
+}
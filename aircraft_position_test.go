@@ -0,0 +1,183 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestVDLM2MessageAircraft(t *testing.T) {
+	tests := []struct {
+		sourceType string
+		wantHex    string
+	}{
+		{"Aircraft", "A1B2C3"},
+		// An uplink's source is the ground station, not the aircraft
+		{"Ground station", ""},
+	}
+	for _, test := range tests {
+		var m VDLM2Message
+		m.VDL2.AVLC.Source.Type = test.sourceType
+		m.VDL2.AVLC.Source.Address = "A1B2C3"
+		m.VDL2.AVLC.ACARS.Registration = ".N12345"
+		if got := VDLM2MessageAircraft(m); got.Hex != test.wantHex || got.Registration != ".N12345" {
+			t.Errorf("%s: got %+v", test.sourceType, got)
+		}
+	}
+}
+
+func TestAircraftPositionPoint(t *testing.T) {
+	messagePoint := TrackPoint{Latitude: 42, Longitude: -83, AltitudeFeet: 33000, HasAltitude: true}
+	tests := []struct {
+		name         string
+		position     AircraftPosition
+		wantFound    bool
+		wantSource   string
+		wantFeet     int64
+		wantAltitude bool
+		wantGround   bool
+	}{
+		{
+			name:     "nothing",
+			position: AircraftPosition{},
+		},
+		{
+			name:         "message only",
+			position:     AircraftPosition{Message: messagePoint, HasMessage: true},
+			wantFound:    true,
+			wantSource:   PositionSourceMessage,
+			wantFeet:     33000,
+			wantAltitude: true,
+		},
+		{
+			name: "live prefers geometric altitude",
+			position: AircraftPosition{
+				Live:    TJSONAircraft{Type: "adsb_icao", Latitude: 40, Longitude: -74, AltimeterBarometer: Tar1090Altitude{Feet: 35000, Known: true}, AltimeterGeometricFeet: 35500},
+				HasLive: true, Message: messagePoint, HasMessage: true,
+			},
+			wantFound:    true,
+			wantSource:   Tar1090PositionSourceADSB,
+			wantFeet:     35500,
+			wantAltitude: true,
+		},
+		{
+			name: "live without an altitude",
+			position: AircraftPosition{
+				Live:    TJSONAircraft{Type: "adsb_icao", Latitude: 40, Longitude: -74},
+				HasLive: true,
+			},
+			wantFound:  true,
+			wantSource: Tar1090PositionSourceADSB,
+		},
+		{
+			name: "live on the ground",
+			position: AircraftPosition{
+				Live:    TJSONAircraft{Type: "adsb_icao", Latitude: 40, Longitude: -74, AltimeterBarometer: Tar1090Altitude{OnGround: true, Known: true}, AltimeterGeometricFeet: 50},
+				HasLive: true,
+			},
+			wantFound:  true,
+			wantSource: Tar1090PositionSourceADSB,
+			wantGround: true,
+		},
+		{
+			name: "live without a position falls back to the message",
+			position: AircraftPosition{
+				Live:    TJSONAircraft{Type: "adsb_icao", AltimeterBarometer: Tar1090Altitude{Feet: 35000, Known: true}},
+				HasLive: true, Message: messagePoint, HasMessage: true,
+			},
+			wantFound:    true,
+			wantSource:   PositionSourceMessage,
+			wantFeet:     33000,
+			wantAltitude: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			point, source, found := test.position.Point()
+			if found != test.wantFound || source != test.wantSource || point.AltitudeFeet != test.wantFeet ||
+				point.HasAltitude != test.wantAltitude || point.OnGround != test.wantGround {
+				t.Errorf("got %+v from %q, found %t", point, source, found)
+			}
+		})
+	}
+}
+
+func TestMessageAircraftPosition(t *testing.T) {
+	previous := config
+	t.Cleanup(func() { config = previous })
+	config.TAR1090URL, config.SBSHost, config.ReadsbJSONHost = "", "", ""
+
+	aircraft := MessageAircraft{Registration: "N12345", Label: "H1", Text: "POS N4012.3W07412.5"}
+	position := aircraft.Position()
+	if position.HasLive || !position.HasMessage || position.Message.HasAltitude {
+		t.Errorf("got %+v", position)
+	}
+	if again := aircraft.Position(); again.HasMessage != position.HasMessage || again.Message != position.Message {
+		t.Errorf("asking again got %+v", again)
+	}
+	if other := (MessageAircraft{Registration: "N12345", Label: "H1", Text: "NO POSITION"}).Position(); other.HasMessage {
+		t.Errorf("a different message reused the last position: %+v", other)
+	}
+}
+
+// Holds up hex lookups for a1b2c3 until released
+type blockingAircraftSource struct {
+	started, release chan struct{}
+}
+
+func (s blockingAircraftSource) LookupHex(hex string) (TJSONAircraft, bool) {
+	if hex == "a1b2c3" {
+		close(s.started)
+		<-s.release
+	}
+	return TJSONAircraft{}, false
+}
+
+func (s blockingAircraftSource) LookupRegistration(string) (TJSONAircraft, bool) {
+	return TJSONAircraft{}, false
+}
+
+func (s blockingAircraftSource) LookupCallsign(string) (TJSONAircraft, bool) {
+	return TJSONAircraft{}, false
+}
+
+func (s blockingAircraftSource) DataAge() (time.Duration, bool) {
+	return 0, false
+}
+
+func TestMessageAircraftPositionDoesNotWait(t *testing.T) {
+	source := blockingAircraftSource{started: make(chan struct{}), release: make(chan struct{})}
+	useAircraftSource(t, source)
+	config.TAR1090URL, config.SBSHost, config.ReadsbJSONHost = "http://tar1090.invalid", "", ""
+
+	slow := make(chan struct{})
+	go func() {
+		MessageAircraft{Hex: "a1b2c3"}.Position()
+		close(slow)
+	}()
+	<-source.started
+	done := make(chan AircraftPosition)
+	go func() {
+		done <- MessageAircraft{Hex: "d4e5f6", Label: "H1", Text: "POS N4012.3W07412.5"}.Position()
+	}()
+	select {
+	case position := <-done:
+		if !position.HasMessage {
+			t.Errorf("got %+v", position)
+		}
+	case <-time.After(time.Second):
+		t.Error("waited on another aircraft's lookup")
+	}
+	close(source.release)
+	<-slow
+}
+
+func TestMessageTrackPointADSC(t *testing.T) {
+	point, found := MessageTrackPoint("H1", "/BOMASAI.ADS.VT-ANB072501A070A988CA73248F0E5DC10200000F5EE1ABC000102B885E0A19F5")
+	if !found || math.Abs(point.Latitude-52.0402) > 0.001 || math.Abs(point.Longitude-19.8039) > 0.001 {
+		t.Fatalf("got %+v, found %t", point, found)
+	}
+	if !point.HasAltitude || point.AltitudeFeet != 36004 {
+		t.Errorf("got altitude %d, known %t", point.AltitudeFeet, point.HasAltitude)
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Somewhere to look up what aircraft are doing right now. Both the tar1090
+// poller and the SBS/readsb stream state table satisfy this.
+type AircraftSource interface {
+	LookupHex(hex string) (TJSONAircraft, bool)
+	LookupRegistration(reg string) (TJSONAircraft, bool)
+	LookupCallsign(callsign string) (TJSONAircraft, bool)
+	// How old the data is, or false if there isn't any yet
+	DataAge() (age time.Duration, ok bool)
+}
+
+var aircraftSource AircraftSource = tar1090Cache
+
+const defaultAircraftStateMaxAgeSeconds = 300
+
+// Reports whether any source of aircraft positions is configured
+func AircraftPositionsEnabled() bool {
+	return config.TAR1090URL != "" || AircraftStreamEnabled()
+}
+
+func AircraftStreamEnabled() bool {
+	return config.SBSHost != "" || config.ReadsbJSONHost != ""
+}
+
+type aircraftStateEntry struct {
+	aircraft     TJSONAircraft
+	lastSeen     time.Time
+	lastPosition time.Time
+}
+
+// Aircraft built up from a live SBS or readsb JSON stream, keyed by ICAO hex
+type AircraftStateTable struct {
+	mu          sync.RWMutex
+	aircraft    map[string]*aircraftStateEntry
+	maxAge      time.Duration
+	lastMessage time.Time
+}
+
+func NewAircraftStateTable(maxAge time.Duration) *AircraftStateTable {
+	return &AircraftStateTable{aircraft: map[string]*aircraftStateEntry{}, maxAge: maxAge}
+}
+
+var aircraftState *AircraftStateTable
+
+// Starts reading the configured streams and serves lookups from them
+// instead of tar1090
+func ConfigureAircraftState() {
+	if !AircraftStreamEnabled() {
+		return
+	}
+	maxAge := config.AircraftStateMaxAgeSeconds
+	if maxAge <= 0 {
+		maxAge = defaultAircraftStateMaxAgeSeconds
+	}
+	aircraftState = NewAircraftStateTable(time.Duration(maxAge) * time.Second)
+	aircraftSource = aircraftState
+	go aircraftState.PruneEvery(time.Minute)
+	if config.SBSHost != "" {
+		go ReadSBSStream()
+	}
+	if config.ReadsbJSONHost != "" {
+		go ReadReadsbJSONStream()
+	}
+}
+
+// Applies changes to an aircraft, creating it if it's new
+func (t *AircraftStateTable) Update(hex string, update func(aircraft *TJSONAircraft) (hasPosition bool)) {
+	hex = strings.ToLower(strings.TrimSpace(hex))
+	if hex == "" {
+		return
+	}
+	now := time.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entry, ok := t.aircraft[hex]
+	if !ok {
+		entry = &aircraftStateEntry{aircraft: TJSONAircraft{Hex: hex}}
+		t.aircraft[hex] = entry
+	}
+	if update(&entry.aircraft) {
+		entry.lastPosition = now
+		trackHistory.RecordAircraft(entry.aircraft)
+	}
+	entry.aircraft.MessageCount++
+	entry.lastSeen = now
+	t.lastMessage = now
+}
+
+// Copies out an aircraft with its seen times filled in
+func (t *AircraftStateTable) snapshot(entry *aircraftStateEntry) (TJSONAircraft, bool) {
+	if time.Since(entry.lastSeen) > t.maxAge {
+		return TJSONAircraft{}, false
+	}
+	aircraft := entry.aircraft
+	aircraft.Seen = time.Since(entry.lastSeen).Seconds()
+	if !entry.lastPosition.IsZero() {
+		aircraft.SeenPosition = time.Since(entry.lastPosition).Seconds()
+	}
+	return aircraft, true
+}
+
+func (t *AircraftStateTable) LookupHex(hex string) (TJSONAircraft, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	entry, ok := t.aircraft[strings.ToLower(strings.TrimSpace(hex))]
+	if !ok {
+		return TJSONAircraft{}, false
+	}
+	return t.snapshot(entry)
+}
+
+// SBS doesn't carry registrations, so this relies on readsb's database or
+// the aircraft database to get the hex
+func (t *AircraftStateTable) LookupRegistration(reg string) (TJSONAircraft, bool) {
+	if record, found := aircraftDB.LookupRegistration(reg); found && record.ICAOHex != "" {
+		if aircraft, found := t.LookupHex(record.ICAOHex); found {
+			return aircraft, true
+		}
+	}
+	return t.find(func(aircraft TJSONAircraft) bool {
+		return aircraft.Registration != "" && NormalizeAircraftRegistration(aircraft.Registration) == NormalizeAircraftRegistration(reg)
+	})
+}
+
+func (t *AircraftStateTable) LookupCallsign(callsign string) (TJSONAircraft, bool) {
+	callsign = strings.ToUpper(strings.TrimSpace(callsign))
+	return t.find(func(aircraft TJSONAircraft) bool {
+		return strings.ToUpper(strings.TrimSpace(aircraft.AircraftTailCode)) == callsign
+	})
+}
+
+// The table only holds aircraft in range, so a scan is cheap
+func (t *AircraftStateTable) find(matches func(TJSONAircraft) bool) (TJSONAircraft, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	for _, entry := range t.aircraft {
+		if matches(entry.aircraft) {
+			if aircraft, ok := t.snapshot(entry); ok {
+				return aircraft, true
+			}
+		}
+	}
+	return TJSONAircraft{}, false
+}
+
+// How long since anything was heard on the stream
+func (t *AircraftStateTable) DataAge() (age time.Duration, ok bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if t.lastMessage.IsZero() {
+		return 0, false
+	}
+	return time.Since(t.lastMessage), true
+}
+
+func (t *AircraftStateTable) PruneEvery(interval time.Duration) {
+	for range time.Tick(interval) {
+		t.mu.Lock()
+		for hex, entry := range t.aircraft {
+			if time.Since(entry.lastSeen) > t.maxAge {
+				delete(t.aircraft, hex)
+			}
+		}
+		log.Debugf("tracking %d aircraft from stream", len(t.aircraft))
+		t.mu.Unlock()
+	}
+}
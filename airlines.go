@@ -0,0 +1,115 @@
+package main
+
+type Airline struct {
+	IATA     string
+	ICAO     string
+	Name     string
+	Country  string
+	Callsign string
+}
+
+// Airlines commonly seen on ACARS. Flight IDs usually start with the IATA
+// code but some operators send their ICAO code instead.
+var Airlines = []Airline{
+	{"AA", "AAL", "American Airlines", "United States", "AMERICAN"},
+	{"AC", "ACA", "Air Canada", "Canada", "AIR CANADA"},
+	{"AF", "AFR", "Air France", "France", "AIRFRANS"},
+	{"AI", "AIC", "Air India", "India", "AIRINDIA"},
+	{"AM", "AMX", "Aeromexico", "Mexico", "AEROMEXICO"},
+	{"AR", "ARG", "Aerolineas Argentinas", "Argentina", "ARGENTINA"},
+	{"AS", "ASA", "Alaska Airlines", "United States", "ALASKA"},
+	{"AV", "AVA", "Avianca", "Colombia", "AVIANCA"},
+	{"AY", "FIN", "Finnair", "Finland", "FINNAIR"},
+	{"AZ", "ITY", "ITA Airways", "Italy", "ITARROW"},
+	{"B6", "JBU", "JetBlue Airways", "United States", "JETBLUE"},
+	{"BA", "BAW", "British Airways", "United Kingdom", "SPEEDBIRD"},
+	{"BR", "EVA", "EVA Air", "Taiwan", "EVA"},
+	{"BT", "BTI", "airBaltic", "Latvia", "AIRBALTIC"},
+	{"CA", "CCA", "Air China", "China", "AIR CHINA"},
+	{"CI", "CAL", "China Airlines", "Taiwan", "DYNASTY"},
+	{"CM", "CMP", "Copa Airlines", "Panama", "COPA"},
+	{"CV", "CLX", "Cargolux", "Luxembourg", "CARGOLUX"},
+	{"CX", "CPA", "Cathay Pacific", "Hong Kong", "CATHAY"},
+	{"CZ", "CSN", "China Southern Airlines", "China", "CHINA SOUTHERN"},
+	{"DE", "CFG", "Condor", "Germany", "CONDOR"},
+	{"DL", "DAL", "Delta Air Lines", "United States", "DELTA"},
+	{"EI", "EIN", "Aer Lingus", "Ireland", "SHAMROCK"},
+	{"EK", "UAE", "Emirates", "United Arab Emirates", "EMIRATES"},
+	{"ET", "ETH", "Ethiopian Airlines", "Ethiopia", "ETHIOPIAN"},
+	{"EW", "EWG", "Eurowings", "Germany", "EUROWINGS"},
+	{"EY", "ETD", "Etihad Airways", "United Arab Emirates", "ETIHAD"},
+	{"F9", "FFT", "Frontier Airlines", "United States", "FRONTIER FLIGHT"},
+	{"FI", "ICE", "Icelandair", "Iceland", "ICEAIR"},
+	{"FR", "RYR", "Ryanair", "Ireland", "RYANAIR"},
+	{"FX", "FDX", "FedEx", "United States", "FEDEX"},
+	{"G4", "AAY", "Allegiant Air", "United States", "ALLEGIANT"},
+	{"GA", "GIA", "Garuda Indonesia", "Indonesia", "INDONESIA"},
+	{"HA", "HAL", "Hawaiian Airlines", "United States", "HAWAIIAN"},
+	{"HU", "CHH", "Hainan Airlines", "China", "HAINAN"},
+	{"IB", "IBE", "Iberia", "Spain", "IBERIA"},
+	{"JL", "JAL", "Japan Airlines", "Japan", "JAPANAIR"},
+	{"KE", "KAL", "Korean Air", "South Korea", "KOREANAIR"},
+	{"KL", "KLM", "KLM Royal Dutch Airlines", "Netherlands", "KLM"},
+	{"KQ", "KQA", "Kenya Airways", "Kenya", "KENYA"},
+	{"LA", "LAN", "LATAM Airlines", "Chile", "LAN CHILE"},
+	{"LH", "DLH", "Lufthansa", "Germany", "LUFTHANSA"},
+	{"LO", "LOT", "LOT Polish Airlines", "Poland", "LOT"},
+	{"LX", "SWR", "Swiss International Air Lines", "Switzerland", "SWISS"},
+	{"LY", "ELY", "El Al", "Israel", "ELAL"},
+	{"MH", "MAS", "Malaysia Airlines", "Malaysia", "MALAYSIAN"},
+	{"MS", "MSR", "EgyptAir", "Egypt", "EGYPTAIR"},
+	{"MU", "CES", "China Eastern Airlines", "China", "CHINA EASTERN"},
+	{"MX", "MXY", "Breeze Airways", "United States", "MOXY"},
+	{"NH", "ANA", "All Nippon Airways", "Japan", "ALL NIPPON"},
+	{"NK", "NKS", "Spirit Airlines", "United States", "SPIRIT WINGS"},
+	{"NZ", "ANZ", "Air New Zealand", "New Zealand", "NEW ZEALAND"},
+	{"OS", "AUA", "Austrian Airlines", "Austria", "AUSTRIAN"},
+	{"OZ", "AAR", "Asiana Airlines", "South Korea", "ASIANA"},
+	{"PR", "PAL", "Philippine Airlines", "Philippines", "PHILIPPINE"},
+	{"QF", "QFA", "Qantas", "Australia", "QANTAS"},
+	{"QR", "QTR", "Qatar Airways", "Qatar", "QATARI"},
+	{"QX", "QXE", "Horizon Air", "United States", "HORIZON"},
+	{"RJ", "RJA", "Royal Jordanian", "Jordan", "JORDANIAN"},
+	{"SA", "SAA", "South African Airways", "South Africa", "SPRINGBOK"},
+	{"SK", "SAS", "Scandinavian Airlines", "Sweden", "SCANDINAVIAN"},
+	{"SN", "BEL", "Brussels Airlines", "Belgium", "BEELINE"},
+	{"SQ", "SIA", "Singapore Airlines", "Singapore", "SINGAPORE"},
+	{"SU", "AFL", "Aeroflot", "Russia", "AEROFLOT"},
+	{"SV", "SVA", "Saudia", "Saudi Arabia", "SAUDIA"},
+	{"SY", "SCX", "Sun Country Airlines", "United States", "SUN COUNTRY"},
+	{"TG", "THA", "Thai Airways", "Thailand", "THAI"},
+	{"TK", "THY", "Turkish Airlines", "Turkey", "TURKISH"},
+	{"TP", "TAP", "TAP Air Portugal", "Portugal", "AIR PORTUGAL"},
+	{"U2", "EZY", "easyJet", "United Kingdom", "EASY"},
+	{"UA", "UAL", "United Airlines", "United States", "UNITED"},
+	{"VA", "VOZ", "Virgin Australia", "Australia", "VELOCITY"},
+	{"VN", "HVN", "Vietnam Airlines", "Vietnam", "VIET NAM AIRLINES"},
+	{"VS", "VIR", "Virgin Atlantic", "United Kingdom", "VIRGIN"},
+	{"W6", "WZZ", "Wizz Air", "Hungary", "WIZZ AIR"},
+	{"WN", "SWA", "Southwest Airlines", "United States", "SOUTHWEST"},
+	{"WS", "WJA", "WestJet", "Canada", "WESTJET"},
+	{"YX", "RPA", "Republic Airways", "United States", "BRICKYARD"},
+	{"OO", "SKW", "SkyWest Airlines", "United States", "SKYWEST"},
+	{"9E", "EDV", "Endeavor Air", "United States", "ENDEAVOR"},
+	{"MQ", "ENY", "Envoy Air", "United States", "ENVOY"},
+	{"OH", "JIA", "PSA Airlines", "United States", "BLUE STREAK"},
+	{"5X", "UPS", "UPS Airlines", "United States", "UPS"},
+	{"5Y", "GTI", "Atlas Air", "United States", "GIANT"},
+	{"K4", "CKS", "Kalitta Air", "United States", "CONNIE"},
+	{"PO", "PAC", "Polar Air Cargo", "United States", "POLAR"},
+	{"3S", "BOX", "AeroLogic", "Germany", "GERMAN CARGO"},
+	{"QY", "BCS", "European Air Transport", "Germany", "EUROTRANS"},
+	{"LD", "AHK", "Air Hong Kong", "Hong Kong", "AIR HONG KONG"},
+}
+
+var (
+	airlinesByIATA = map[string]Airline{}
+	airlinesByICAO = map[string]Airline{}
+)
+
+func init() {
+	for _, airline := range Airlines {
+		airlinesByIATA[airline.IATA] = airline
+		airlinesByICAO[airline.ICAO] = airline
+	}
+}
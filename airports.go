@@ -0,0 +1,163 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/jftuga/geodist"
+	log "github.com/sirupsen/logrus"
+)
+
+type Airport struct {
+	ICAO      string
+	IATA      string
+	Type      string
+	Name      string
+	City      string
+	Country   string
+	Latitude  float64
+	Longitude float64
+}
+
+// Airports loaded from an OurAirports airports.csv, indexed by ICAO and IATA
+// code. nearby holds only the airport types considered for the nearest
+// airport.
+type AirportDatabase struct {
+	byCode map[string]Airport
+	nearby []Airport
+}
+
+var airportDB *AirportDatabase
+
+// Types of airport to consider when finding the nearest one, so a position
+// over a city doesn't resolve to a hospital heliport
+const defaultNearestAirportTypes = "large_airport,medium_airport"
+
+func ConfigureAirportDatabase() {
+	if config.AirportsFile == "" {
+		return
+	}
+	nearestTypes := config.AirportsNearestTypes
+	if nearestTypes == "" {
+		nearestTypes = defaultNearestAirportTypes
+	}
+	db, err := LoadAirportDatabase(config.AirportsFile, strings.Split(nearestTypes, ","))
+	if err != nil {
+		log.Fatalf("error loading airports: %s", err)
+	}
+	log.Infof("loaded %d airports from %s", len(db.nearby), config.AirportsFile)
+	airportDB = db
+}
+
+// Reads an OurAirports style CSV. Columns are found by name so extra or
+// reordered columns are fine.
+func LoadAirportDatabase(path string, nearestTypes []string) (*AirportDatabase, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	r := csv.NewReader(file)
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	header, err := r.Read()
+	if err != nil {
+		return nil, err
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+	for _, required := range []string{"ident", "name", "latitude_deg", "longitude_deg"} {
+		if _, ok := columns[required]; !ok {
+			return nil, errors.New("airports file is missing the " + required + " column")
+		}
+	}
+
+	db := &AirportDatabase{byCode: map[string]Airport{}}
+	for {
+		row, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		field := func(column string) string {
+			if i, ok := columns[column]; ok && i < len(row) {
+				return strings.TrimSpace(row[i])
+			}
+			return ""
+		}
+		if field("type") == "closed" {
+			continue
+		}
+		lat, latErr := strconv.ParseFloat(field("latitude_deg"), 64)
+		lon, lonErr := strconv.ParseFloat(field("longitude_deg"), 64)
+		if latErr != nil || lonErr != nil {
+			continue
+		}
+		icao := field("icao_code")
+		if icao == "" {
+			icao = field("gps_code")
+		}
+		if ident := field("ident"); icao == "" && len(ident) == 4 {
+			icao = ident
+		}
+		airport := Airport{
+			ICAO:      strings.ToUpper(icao),
+			IATA:      strings.ToUpper(field("iata_code")),
+			Type:      field("type"),
+			Name:      field("name"),
+			City:      field("municipality"),
+			Country:   field("iso_country"),
+			Latitude:  lat,
+			Longitude: lon,
+		}
+		// ICAO codes are four characters and IATA three so these can't collide
+		if len(airport.ICAO) == 4 {
+			db.byCode[airport.ICAO] = airport
+		}
+		if len(airport.IATA) == 3 {
+			db.byCode[airport.IATA] = airport
+		}
+		for _, t := range nearestTypes {
+			if strings.TrimSpace(t) == airport.Type {
+				db.nearby = append(db.nearby, airport)
+				break
+			}
+		}
+	}
+	return db, nil
+}
+
+// Finds an airport by ICAO or IATA code
+func (db *AirportDatabase) Lookup(code string) (Airport, bool) {
+	if db == nil {
+		return Airport{}, false
+	}
+	airport, ok := db.byCode[strings.ToUpper(strings.TrimSpace(code))]
+	return airport, ok
+}
+
+// Returns the closest airport to a position and the distance to it in km
+func (db *AirportDatabase) Nearest(lat, lon float64) (nearest Airport, km float64, found bool) {
+	if db == nil {
+		return nearest, 0, false
+	}
+	position := geodist.Coord{Lat: lat, Lon: lon}
+	km = math.Inf(1)
+	for _, airport := range db.nearby {
+		_, distance := geodist.HaversineDistance(position, geodist.Coord{Lat: airport.Latitude, Lon: airport.Longitude})
+		if distance < km {
+			nearest, km, found = airport, distance, true
+		}
+	}
+	return nearest, km, found
+}
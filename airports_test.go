@@ -0,0 +1,86 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const testAirportsCSV = `"id","ident","type","name","latitude_deg","longitude_deg","iso_country","municipality","gps_code","iata_code","icao_code"
+1,"KORD","large_airport","Chicago O'Hare International Airport",41.9786,-87.9048,"US","Chicago","KORD","ORD","KORD"
+2,"KLAX","large_airport","Los Angeles International Airport",33.9425,-118.408,"US","Los Angeles","KLAX","LAX",""
+3,"IL01","heliport","Hospital Heliport",41.9,-87.9,"US","Chicago","IL01","",""
+4,"KXXX","closed","Closed Field",41.95,-87.95,"US","Chicago","","XXX",""
+5,"KMDW","medium_airport","Chicago Midway International Airport",41.786,-87.7524,"US","Chicago","KMDW","MDW","KMDW"
+`
+
+func testAirportDatabase(t *testing.T) *AirportDatabase {
+	db, err := LoadAirportDatabase(writeTestFile(t, "airports.csv", testAirportsCSV), strings.Split(defaultNearestAirportTypes, ","))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestAirportDatabaseLookup(t *testing.T) {
+	db := testAirportDatabase(t)
+	tests := []struct {
+		code, want string
+		found      bool
+	}{
+		{"KORD", "Chicago O'Hare International Airport", true},
+		{" ord ", "Chicago O'Hare International Airport", true},
+		// No icao_code, so the GPS code stands in
+		{"KLAX", "Los Angeles International Airport", true},
+		{"IL01", "Hospital Heliport", true},
+		{"XXX", "", false},
+		{"", "", false},
+	}
+	for _, test := range tests {
+		airport, found := db.Lookup(test.code)
+		if airport.Name != test.want || found != test.found {
+			t.Errorf("%q: got %q %t", test.code, airport.Name, found)
+		}
+	}
+	if _, err := LoadAirportDatabase(writeTestFile(t, "bad.csv", "ident,name\nKORD,O'Hare\n"), nil); err == nil {
+		t.Error("expected an error for missing columns")
+	}
+}
+
+func TestAirportDatabaseNearest(t *testing.T) {
+	db := testAirportDatabase(t)
+	// Right on top of the heliport, which isn't a type considered nearby
+	airport, km, found := db.Nearest(41.9, -87.9)
+	if !found || airport.ICAO != "KORD" || km > 10 {
+		t.Errorf("got %s %f %t", airport.ICAO, km, found)
+	}
+	if airport, _, _ := db.Nearest(41.78, -87.75); airport.ICAO != "KMDW" {
+		t.Errorf("got %s, want KMDW", airport.ICAO)
+	}
+	var none *AirportDatabase
+	if _, _, found := none.Nearest(0, 0); found {
+		t.Error("a nil database found an airport")
+	}
+}
+
+func TestAnnotateAirports(t *testing.T) {
+	previous, previousConfig := airportDB, config
+	t.Cleanup(func() { airportDB, config = previous, previousConfig })
+	airportDB = testAirportDatabase(t)
+	config.TAR1090URL, config.SBSHost, config.ReadsbJSONHost = "", "", ""
+
+	annotation := AirportAnnotator{}.AnnotateAirports(MessageAircraft{Registration: "N12345", Label: "QB", Text: "KORDKLAX1523 0123"})
+	if annotation["routeOriginIATA"] != "ORD" || annotation["routeDestinationCity"] != "Los Angeles" {
+		t.Errorf("got %v", annotation)
+	}
+	if _, ok := annotation["nearestAirportICAO"]; ok {
+		t.Error("nearest airport without a position")
+	}
+
+	annotation = AirportAnnotator{}.AnnotateAirports(MessageAircraft{Registration: "N12345", Label: "H1", Text: "POS N4147.0W08745.0"})
+	if annotation["nearestAirportICAO"] != "KMDW" {
+		t.Errorf("got %v", annotation)
+	}
+	if got := (AirportAnnotator{}).AnnotateAirports(MessageAircraft{Registration: "N12345", Label: "H1", Text: "HELLO"}); got != nil {
+		t.Errorf("got %v, want nothing", got)
+	}
+}
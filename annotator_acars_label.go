@@ -0,0 +1,227 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Message categories for labels
+const (
+	ACARSCategoryOOOI           = "OOOI"
+	ACARSCategoryPosition       = "position"
+	ACARSCategoryWeather        = "weather"
+	ACARSCategoryFreeText       = "free text"
+	ACARSCategoryMaintenance    = "maintenance"
+	ACARSCategoryLinkTest       = "link test"
+	ACARSCategoryATC            = "ATC"
+	ACARSCategoryAirlineDefined = "airline defined"
+	ACARSCategoryOther          = "other"
+)
+
+const (
+	ACARSDirectionUplink   = "uplink"
+	ACARSDirectionDownlink = "downlink"
+	ACARSDirectionUnknown  = "unknown"
+)
+
+type ACARSLabelInfo struct {
+	Description string
+	Category    string
+	// Set if the label only goes one way, regardless of block ID
+	Direction string
+}
+
+// ARINC 618/620 labels
+var ACARSLabels = map[string]ACARSLabelInfo{
+	"_d":      {"General response, no information to transmit", ACARSCategoryLinkTest, ""},
+	"_\u007f": {"General response, no information to transmit", ACARSCategoryLinkTest, ""},
+	"Q0":      {"Link test", ACARSCategoryLinkTest, ACARSDirectionDownlink},
+	"SQ":      {"Squitter (ground station broadcast)", ACARSCategoryLinkTest, ACARSDirectionUplink},
+	"SA":      {"Media advisory", ACARSCategoryLinkTest, ACARSDirectionDownlink},
+	"S1":      {"Network statistics", ACARSCategoryLinkTest, ""},
+	":;":      {"Data transceiver autotune", ACARSCategoryLinkTest, ACARSDirectionUplink},
+	"F3":      {"Dedicated transceiver advisory", ACARSCategoryLinkTest, ACARSDirectionDownlink},
+	"5P":      {"Temporary suspension of ACARS", ACARSCategoryLinkTest, ACARSDirectionDownlink},
+	"5V":      {"VDL switch advisory", ACARSCategoryLinkTest, ACARSDirectionDownlink},
+	"Q1":      {"Departure/arrival report", ACARSCategoryOOOI, ACARSDirectionDownlink},
+	"Q2":      {"ETA report", ACARSCategoryOOOI, ACARSDirectionDownlink},
+	"Q3":      {"Clock update", ACARSCategoryOther, ACARSDirectionDownlink},
+	"Q4":      {"Voice circuit busy", ACARSCategoryOther, ACARSDirectionDownlink},
+	"Q5":      {"Unable to deliver uplinked message", ACARSCategoryOther, ACARSDirectionDownlink},
+	"Q6":      {"Voice to ACARS changeover", ACARSCategoryOther, ACARSDirectionDownlink},
+	"Q7":      {"Delay message", ACARSCategoryOOOI, ACARSDirectionDownlink},
+	"QA":      {"OUT/fuel report", ACARSCategoryOOOI, ACARSDirectionDownlink},
+	"QB":      {"OFF report", ACARSCategoryOOOI, ACARSDirectionDownlink},
+	"QC":      {"ON report", ACARSCategoryOOOI, ACARSDirectionDownlink},
+	"QD":      {"IN/fuel report", ACARSCategoryOOOI, ACARSDirectionDownlink},
+	"QE":      {"OUT/fuel/destination report", ACARSCategoryOOOI, ACARSDirectionDownlink},
+	"QF":      {"OFF/destination report", ACARSCategoryOOOI, ACARSDirectionDownlink},
+	"QG":      {"OUT/return IN report", ACARSCategoryOOOI, ACARSDirectionDownlink},
+	"QH":      {"OUT report", ACARSCategoryOOOI, ACARSDirectionDownlink},
+	"QK":      {"Landing report", ACARSCategoryOOOI, ACARSDirectionDownlink},
+	"QL":      {"Arrival report", ACARSCategoryOOOI, ACARSDirectionDownlink},
+	"QM":      {"Arrival information report", ACARSCategoryOOOI, ACARSDirectionDownlink},
+	"QN":      {"Diversion report", ACARSCategoryOOOI, ACARSDirectionDownlink},
+	"QP":      {"OUT report", ACARSCategoryOOOI, ACARSDirectionDownlink},
+	"QQ":      {"OFF report", ACARSCategoryOOOI, ACARSDirectionDownlink},
+	"QR":      {"ON report", ACARSCategoryOOOI, ACARSDirectionDownlink},
+	"QS":      {"IN report", ACARSCategoryOOOI, ACARSDirectionDownlink},
+	"QT":      {"OUT/return IN report", ACARSCategoryOOOI, ACARSDirectionDownlink},
+	"51":      {"Ground GMT request/response", ACARSCategoryOther, ""},
+	"54":      {"Aircrew initiated voice contact request", ACARSCategoryOther, ACARSDirectionDownlink},
+	"57":      {"Alternate aircrew initiated position report", ACARSCategoryPosition, ACARSDirectionDownlink},
+	"5D":      {"ATIS request", ACARSCategoryWeather, ACARSDirectionDownlink},
+	"5U":      {"Weather request", ACARSCategoryWeather, ACARSDirectionDownlink},
+	"5Z":      {"Airline designated downlink", ACARSCategoryFreeText, ACARSDirectionDownlink},
+	"7A":      {"Aircraft initiated engine data", ACARSCategoryMaintenance, ACARSDirectionDownlink},
+	"7B":      {"Aircraft initiated miscellaneous message", ACARSCategoryOther, ACARSDirectionDownlink},
+	"H1":      {"Message to/from terminal", ACARSCategoryOther, ""},
+	"H2":      {"Meteorological report", ACARSCategoryWeather, ACARSDirectionDownlink},
+	"RA":      {"Command aircraft terminal to print message", ACARSCategoryFreeText, ACARSDirectionUplink},
+	"RB":      {"Response to aircraft", ACARSCategoryFreeText, ACARSDirectionUplink},
+	"AA":      {"ARINC 622 ATS uplink (CPDLC/ADS-C)", ACARSCategoryATC, ACARSDirectionUplink},
+	"BA":      {"ARINC 622 ATS downlink (CPDLC/ADS-C)", ACARSCategoryATC, ACARSDirectionDownlink},
+	"A0":      {"ATIS facilities notification", ACARSCategoryATC, ACARSDirectionUplink},
+	"A1":      {"Deliver oceanic clearance", ACARSCategoryATC, ACARSDirectionUplink},
+	"A3":      {"Deliver departure clearance", ACARSCategoryATC, ACARSDirectionUplink},
+	"A4":      {"Acknowledge departure clearance", ACARSCategoryATC, ACARSDirectionUplink},
+	"A5":      {"Request position report", ACARSCategoryPosition, ACARSDirectionUplink},
+	"A6":      {"Request ADS report", ACARSCategoryPosition, ACARSDirectionUplink},
+	"A7":      {"Forward free text to aircraft", ACARSCategoryFreeText, ACARSDirectionUplink},
+	"A8":      {"Deliver departure slot", ACARSCategoryATC, ACARSDirectionUplink},
+	"A9":      {"Deliver ATIS information", ACARSCategoryWeather, ACARSDirectionUplink},
+	"B0":      {"ATS facility notification", ACARSCategoryATC, ACARSDirectionDownlink},
+	"B1":      {"Request oceanic clearance", ACARSCategoryATC, ACARSDirectionDownlink},
+	"B2":      {"Oceanic clearance readback", ACARSCategoryATC, ACARSDirectionDownlink},
+	"B3":      {"Request departure clearance", ACARSCategoryATC, ACARSDirectionDownlink},
+	"B4":      {"Acknowledge departure clearance", ACARSCategoryATC, ACARSDirectionDownlink},
+	"B5":      {"Provide position report", ACARSCategoryPosition, ACARSDirectionDownlink},
+	"B6":      {"Provide ADS report", ACARSCategoryPosition, ACARSDirectionDownlink},
+	"B7":      {"Forward free text to ATS", ACARSCategoryFreeText, ACARSDirectionDownlink},
+	"B8":      {"Request departure slot", ACARSCategoryATC, ACARSDirectionDownlink},
+	"B9":      {"Request ATIS", ACARSCategoryWeather, ACARSDirectionDownlink},
+}
+
+// Sublabels used with label H1, which say which avionics the message is
+// to or from
+var ACARSSublabels = map[string]string{
+	"M1": "Flight management computer, left",
+	"M2": "Flight management computer, right",
+	"M3": "Flight management computer, center",
+	"MD": "Multifunction control display unit",
+	"DF": "Digital flight data acquisition unit",
+	"CF": "Central fault display",
+	"EC": "Engine display system",
+	"EI": "Engine indicating system",
+	"CS": "Cabin terminal",
+	"PS": "Printer",
+}
+
+// Message function identifiers that follow FMC sublabels and what they mean
+var ACARSMessageFunctions = map[string]ACARSLabelInfo{
+	"POS": {"Position report", ACARSCategoryPosition, ACARSDirectionDownlink},
+	"PRG": {"Progress report", ACARSCategoryPosition, ACARSDirectionDownlink},
+	"FPN": {"Flight plan", ACARSCategoryOther, ""},
+	"PER": {"Performance data", ACARSCategoryOther, ""},
+	"WXR": {"Weather request", ACARSCategoryWeather, ACARSDirectionDownlink},
+	"WND": {"Wind data", ACARSCategoryWeather, ""},
+	"REQ": {"Request", ACARSCategoryOther, ACARSDirectionDownlink},
+}
+
+// ex: "- #M1BPOS...", "#DFB..." or "#M1BPRG..."
+var acarsSublabelRegex = regexp.MustCompile(`^(?:- )?#([A-Z0-9]{2})B?([A-Z]{3})?`)
+
+// Returns the uplink/downlink direction. Downlinks have numeric block IDs and
+// uplinks alphabetic ones, otherwise the label might tell us. The mode isn't
+// used: it's the same Category A "2" or Category B letter both ways, so it
+// says which link the message was on but not which way it went.
+func ACARSDirection(blockID string, info ACARSLabelInfo) string {
+	if blockID != "" {
+		switch c := blockID[0]; {
+		case c >= '0' && c <= '9':
+			return ACARSDirectionDownlink
+		case (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z'):
+			return ACARSDirectionUplink
+		}
+	}
+	if info.Direction != "" {
+		return info.Direction
+	}
+	return ACARSDirectionUnknown
+}
+
+// Looks up a label, falling back to the ranges ARINC 620 leaves to airlines
+func LookupACARSLabel(label string) (info ACARSLabelInfo, found bool) {
+	if info, found = ACARSLabels[label]; found {
+		return info, found
+	}
+	if len(label) == 2 {
+		switch label[0] {
+		case 'C':
+			return ACARSLabelInfo{"Uplink to cockpit printer", ACARSCategoryFreeText, ACARSDirectionUplink}, true
+		case '1', '2', '3', '4', '8':
+			return ACARSLabelInfo{"Airline defined", ACARSCategoryAirlineDefined, ""}, true
+		}
+	}
+	return ACARSLabelInfo{"Unknown label", ACARSCategoryOther, ""}, false
+}
+
+// Decodes the label, sublabel and direction of a message
+func DecodeACARSLabel(label, blockID, text string) Annotation {
+	info, _ := LookupACARSLabel(label)
+	annotation := Annotation{
+		"acarsLabelDescription": info.Description,
+		"acarsLabelCategory":    info.Category,
+	}
+	if label == "H1" {
+		if match := acarsSublabelRegex.FindStringSubmatch(text); match != nil {
+			annotation["acarsSublabel"] = match[1]
+			if description, ok := ACARSSublabels[match[1]]; ok {
+				annotation["acarsSublabelDescription"] = description
+			}
+			if function, ok := ACARSMessageFunctions[match[2]]; ok {
+				annotation["acarsMessageFunction"] = match[2]
+				annotation["acarsMessageFunctionDescription"] = function.Description
+				annotation["acarsLabelCategory"] = function.Category
+				if info.Direction == "" {
+					info.Direction = function.Direction
+				}
+			}
+			if match[1] == "CF" || match[1] == "DF" || match[1] == "EI" || match[1] == "EC" {
+				annotation["acarsLabelCategory"] = ACARSCategoryMaintenance
+			}
+		}
+	}
+	annotation["acarsDirection"] = ACARSDirection(blockID, info)
+	return annotation
+}
+
+type ACARSLabelAnnotator struct {
+}
+
+func (a ACARSLabelAnnotator) Name() string {
+	return "acars label"
+}
+
+func (a ACARSLabelAnnotator) SelectFields(annotation Annotation) Annotation {
+	if config.ACARSLabelAnnotatorSelectedFields == "" {
+		return annotation
+	}
+	selectedFields := Annotation{}
+	for field, value := range annotation {
+		if strings.Contains(config.ACARSLabelAnnotatorSelectedFields, field) {
+			selectedFields[field] = value
+		}
+	}
+	return selectedFields
+}
+
+// Interface function to satisfy ACARSHandler
+func (a ACARSLabelAnnotator) AnnotateACARSMessage(m ACARSMessage) (annotation Annotation) {
+	return DecodeACARSLabel(m.Label, m.BlockID, m.MessageText)
+}
+
+// Interface function to satisfy VDLM2Handler
+func (a ACARSLabelAnnotator) AnnotateVDLM2Message(m VDLM2Message) (annotation Annotation) {
+	return DecodeACARSLabel(m.VDL2.AVLC.ACARS.Label, m.VDL2.AVLC.ACARS.BlockID, m.VDL2.AVLC.ACARS.MessageText)
+}
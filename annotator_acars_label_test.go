@@ -0,0 +1,109 @@
+package main
+
+import "testing"
+
+func TestLookupACARSLabel(t *testing.T) {
+	tests := []struct {
+		label         string
+		wantCategory  string
+		wantDirection string
+		wantFound     bool
+	}{
+		{"QA", ACARSCategoryOOOI, ACARSDirectionDownlink, true},
+		{"5Z", ACARSCategoryFreeText, ACARSDirectionDownlink, true},
+		{"SQ", ACARSCategoryLinkTest, ACARSDirectionUplink, true},
+		{"H1", ACARSCategoryOther, "", true},
+		{"BA", ACARSCategoryATC, ACARSDirectionDownlink, true},
+		// Ranges ARINC 620 leaves to airlines
+		{"C7", ACARSCategoryFreeText, ACARSDirectionUplink, true},
+		{"4T", ACARSCategoryAirlineDefined, "", true},
+		{"ZZ", ACARSCategoryOther, "", false},
+		{"", ACARSCategoryOther, "", false},
+	}
+	for _, test := range tests {
+		info, found := LookupACARSLabel(test.label)
+		if info.Category != test.wantCategory || info.Direction != test.wantDirection || found != test.wantFound {
+			t.Errorf("%q: got %+v, found %t", test.label, info, found)
+		}
+	}
+}
+
+func TestACARSDirection(t *testing.T) {
+	tests := []struct {
+		name    string
+		blockID string
+		label   string
+		want    string
+	}{
+		{"numeric block ID", "2", "H1", ACARSDirectionDownlink},
+		{"alphabetic block ID", "S", "H1", ACARSDirectionUplink},
+		{"lowercase block ID", "a", "H1", ACARSDirectionUplink},
+		// The block ID wins over what the label usually is
+		{"block ID over label", "A", "5Z", ACARSDirectionUplink},
+		{"no block ID, one way label", "", "SQ", ACARSDirectionUplink},
+		{"no block ID, either way label", "", "H1", ACARSDirectionUnknown},
+		{"unusual block ID", "~", "QA", ACARSDirectionDownlink},
+	}
+	for _, test := range tests {
+		info, _ := LookupACARSLabel(test.label)
+		if got := ACARSDirection(test.blockID, info); got != test.want {
+			t.Errorf("%s: got %s, want %s", test.name, got, test.want)
+		}
+	}
+}
+
+func TestDecodeACARSLabel(t *testing.T) {
+	tests := []struct {
+		name    string
+		label   string
+		blockID string
+		text    string
+		want    Annotation
+	}{
+		{"OOOI", "QB", "3", "KORDKLAX1523", Annotation{
+			"acarsLabelDescription": "OFF report",
+			"acarsLabelCategory":    ACARSCategoryOOOI,
+			"acarsDirection":        ACARSDirectionDownlink,
+		}},
+		{"FMC position report", "H1", "", "- #M1BPOSN40123W074125", Annotation{
+			"acarsLabelDescription":           "Message to/from terminal",
+			"acarsLabelCategory":              ACARSCategoryPosition,
+			"acarsSublabel":                   "M1",
+			"acarsSublabelDescription":        "Flight management computer, left",
+			"acarsMessageFunction":            "POS",
+			"acarsMessageFunctionDescription": "Position report",
+			"acarsDirection":                  ACARSDirectionDownlink,
+		}},
+		{"maintenance sublabel", "H1", "5", "#DFB A320 ENGINE REPORT", Annotation{
+			"acarsLabelDescription":    "Message to/from terminal",
+			"acarsLabelCategory":       ACARSCategoryMaintenance,
+			"acarsSublabel":            "DF",
+			"acarsSublabelDescription": "Digital flight data acquisition unit",
+			"acarsDirection":           ACARSDirectionDownlink,
+		}},
+		{"unknown sublabel", "H1", "B", "#ZZBXYZ", Annotation{
+			"acarsLabelDescription": "Message to/from terminal",
+			"acarsLabelCategory":    ACARSCategoryOther,
+			"acarsSublabel":         "ZZ",
+			"acarsDirection":        ACARSDirectionUplink,
+		}},
+		{"unknown label", "ZZ", "", "HELLO", Annotation{
+			"acarsLabelDescription": "Unknown label",
+			"acarsLabelCategory":    ACARSCategoryOther,
+			"acarsDirection":        ACARSDirectionUnknown,
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := DecodeACARSLabel(test.label, test.blockID, test.text)
+			if len(got) != len(test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+			for field, want := range test.want {
+				if got[field] != want {
+					t.Errorf("%s: got %v, want %v", field, got[field], want)
+				}
+			}
+		})
+	}
+}
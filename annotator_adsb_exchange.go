@@ -6,18 +6,115 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/jftuga/geodist"
 	log "github.com/sirupsen/logrus"
 )
 
-const adsbapiv2 = "https://adsbexchange-com1.p.rapidapi.com/v2/%s"
-const adsbapikeyheader = "x-rapidapi-key"
+// An ADS-B v2 style API. Paths are relative to BaseURL and take the value
+// being looked up, or latitude, longitude and radius in nautical miles for
+// Point.
+type ADSBAPIProvider struct {
+	BaseURL      string
+	KeyHeader    string
+	Registration string
+	Hex          string
+	Callsign     string
+	Point        string
+}
+
+// Aggregators that serve the ADS-B Exchange v2 response shape
+var ADSBAPIProviders = map[string]ADSBAPIProvider{
+	"adsbexchange": {
+		BaseURL:      "https://adsbexchange-com1.p.rapidapi.com/v2",
+		KeyHeader:    "x-rapidapi-key",
+		Registration: "registration/%s/",
+		Hex:          "hex/%s/",
+		Callsign:     "callsign/%s/",
+		Point:        "lat/%f/lon/%f/dist/%d/",
+	},
+	"adsb.lol": {
+		BaseURL:      "https://api.adsb.lol/v2",
+		Registration: "reg/%s",
+		Hex:          "hex/%s",
+		Callsign:     "callsign/%s",
+		Point:        "point/%f/%f/%d",
+	},
+	"adsb.fi": {
+		BaseURL:      "https://opendata.adsb.fi/api/v2",
+		Registration: "registration/%s",
+		Hex:          "hex/%s",
+		Callsign:     "callsign/%s",
+		Point:        "lat/%f/lon/%f/dist/%d",
+	},
+	"airplanes.live": {
+		BaseURL:      "https://api.airplanes.live/v2",
+		Registration: "reg/%s",
+		Hex:          "hex/%s",
+		Callsign:     "callsign/%s",
+		Point:        "point/%f/%f/%d",
+	},
+}
+
+// How to find the aircraft a message came from
+const (
+	ADSBQueryRegistration = "registration"
+	ADSBQueryHex          = "hex"
+	ADSBQueryCallsign     = "callsign"
+	ADSBQueryPoint        = "point"
+)
+
+const defaultADSBPointRadiusNm = 50
+
+// Warns about an ADSB_API_QUERY that FindAircraft won't recognize, since it
+// would quietly look aircraft up by registration instead
+func ValidateADSBAPIQuery() {
+	switch config.ADSBAPIQuery {
+	case "", ADSBQueryRegistration, ADSBQueryHex, ADSBQueryCallsign, ADSBQueryPoint:
+		return
+	}
+	log.Warnf("unknown ADSB_API_QUERY %q, looking aircraft up by registration", config.ADSBAPIQuery)
+}
+
+// The configured provider with any overrides applied
+func ADSBAPI() ADSBAPIProvider {
+	name := config.ADSBAPIProvider
+	if name == "" {
+		name = "adsbexchange"
+	}
+	provider, ok := ADSBAPIProviders[name]
+	if !ok {
+		log.Warnf("unknown ads-b api provider %q, using adsb.lol style paths", name)
+		provider = ADSBAPIProviders["adsb.lol"]
+	}
+	if config.ADSBAPIURL != "" {
+		provider.BaseURL = config.ADSBAPIURL
+	}
+	if config.ADSBAPIKeyHeader != "" {
+		provider.KeyHeader = config.ADSBAPIKeyHeader
+	}
+	provider.BaseURL = strings.TrimSuffix(provider.BaseURL, "/")
+	return provider
+}
+
+func ADSBAPIKey() string {
+	if config.ADSBAPIKey != "" {
+		return config.ADSBAPIKey
+	}
+	return config.ADSBExchangeAPIKey
+}
+
+// Reports whether the annotator has enough configuration to run
+func ADSBAPIEnabled() bool {
+	return ADSBAPIKey() != "" || config.ADSBAPIProvider != "" || config.ADSBAPIURL != ""
+}
 
 func (a ADSBHandlerAnnotator) Name() string {
-	return "ads-b exchange"
+	return "ads-b api"
 }
 
 func (a ADSBHandlerAnnotator) SelectFields(annotation Annotation) Annotation {
@@ -38,166 +135,298 @@ func (a ADSBHandlerAnnotator) SelectFields(annotation Annotation) Annotation {
 
 type ADSBHandlerAnnotator struct {
 	SingleAircraftPosition SingleAircraftPosition
+	// Resolved once from config when the annotator is enabled
+	Provider ADSBAPIProvider
 }
 
 // https://www.adsbexchange.com/api/aircraft/v2/docs along with some guesswork
 type SingleAircraftPosition struct {
-	Aircraft []struct {
-		HexCode                                       string  `json:"hex"`
-		Type                                          string  `json:"type"`
-		FlightNumber                                  string  `json:"flight"`
-		AircraftTailCode                              string  `json:"r"`
-		AircraftModel                                 string  `json:"t"`
-		AltimeterBarometerFeet                        any     `json:"alt_baro"`
-		AltimeterGeometricFeet                        int64   `json:"alt_geom"`
-		GroundSpeedKnots                              float64 `json:"gs"`
-		TrueGroundTrack                               float64 `json:"track"`
-		AltimeterBarometerRateOfChangeFeet            int64   `json:"baro_rate"`
-		Squawk                                        string  `json:"squawk"`
-		Emergency                                     string  `json:"emergency"`
-		EmitterCategory                               string  `json:"category"`
-		NavAltimeterSettinghPa                        float64 `json:"nav_qnh"`
-		NacAltitudeMCP                                float64 `json:"nav_altitude_mcp"`
-		Latitude                                      float64 `json:"lat"`
-		Longitude                                     float64 `json:"lon"`
-		NavigationIntegrityCategory                   float64 `json:"nic"`
-		RadiusOfContainment                           float64 `json:"rc"`
-		SecondsSincePositionUpdated                   float64 `json:"seen_pos"`
-		Version                                       float64 `json:"version"`
-		NavigationIntegrityCategoryBarometricAltitude float64 `json:"nic_baro"`
-		NavigationalPositionAccuracy                  float64 `json:"nac_p"`
-		NavigationalVelocityAccuracy                  float64 `json:"nac_v"`
-		SourceIntegrityLevel                          float64 `json:"sil"`
-		SourceIntegrityLevelType                      string  `json:"sil_type"`
-		GeometricVerticalAccuracy                     float64 `json:"gva"`
-		SystemDesignAssurance                         float64 `json:"sda"`
-		FlightStatusAlert                             float64 `json:"alert"`
-		SpecialPositionIdentification                 float64 `json:"spi"`
-		MLAT                                          []any   `json:"mlat"`
-		TISB                                          []any   `json:"tisb"`
-		AircraftTotalModeSMessages                    int64   `json:"messages"`
-		SecondsSinceLastMessage                       float64 `json:"seen"`
-		RSSISignalPowerdBm                            float64 `json:"rssi"`
-	} `json:"ac"`
-	Message              string `json:"msg"`
-	APITimestamp         int64  `json:"now"`
-	TotalAircraftResults int64  `json:"total"`
-	CacheTime            int64  `json:"ctime"`
-	ServerProcessingTime int64  `json:"ptime"`
+	Aircraft             []ADSBAircraft `json:"ac"`
+	Message              string         `json:"msg"`
+	APITimestamp         int64          `json:"now"`
+	TotalAircraftResults int64          `json:"total"`
+	CacheTime            int64          `json:"ctime"`
+	ServerProcessingTime int64          `json:"ptime"`
+}
+
+type ADSBAircraft struct {
+	HexCode                                       string          `json:"hex"`
+	Type                                          string          `json:"type"`
+	FlightNumber                                  string          `json:"flight"`
+	AircraftTailCode                              string          `json:"r"`
+	AircraftModel                                 string          `json:"t"`
+	AltimeterBarometer                            Tar1090Altitude `json:"alt_baro"`
+	AltimeterGeometricFeet                        int64           `json:"alt_geom"`
+	GroundSpeedKnots                              float64         `json:"gs"`
+	TrueGroundTrack                               float64         `json:"track"`
+	AltimeterBarometerRateOfChangeFeet            int64           `json:"baro_rate"`
+	Squawk                                        string          `json:"squawk"`
+	Emergency                                     string          `json:"emergency"`
+	EmitterCategory                               string          `json:"category"`
+	NavAltimeterSettinghPa                        float64         `json:"nav_qnh"`
+	NacAltitudeMCP                                float64         `json:"nav_altitude_mcp"`
+	Latitude                                      float64         `json:"lat"`
+	Longitude                                     float64         `json:"lon"`
+	NavigationIntegrityCategory                   float64         `json:"nic"`
+	RadiusOfContainment                           float64         `json:"rc"`
+	SecondsSincePositionUpdated                   float64         `json:"seen_pos"`
+	Version                                       float64         `json:"version"`
+	NavigationIntegrityCategoryBarometricAltitude float64         `json:"nic_baro"`
+	NavigationalPositionAccuracy                  float64         `json:"nac_p"`
+	NavigationalVelocityAccuracy                  float64         `json:"nac_v"`
+	SourceIntegrityLevel                          float64         `json:"sil"`
+	SourceIntegrityLevelType                      string          `json:"sil_type"`
+	GeometricVerticalAccuracy                     float64         `json:"gva"`
+	SystemDesignAssurance                         float64         `json:"sda"`
+	FlightStatusAlert                             float64         `json:"alert"`
+	SpecialPositionIdentification                 float64         `json:"spi"`
+	MLAT                                          []any           `json:"mlat"`
+	TISB                                          []any           `json:"tisb"`
+	AircraftTotalModeSMessages                    int64           `json:"messages"`
+	SecondsSinceLastMessage                       float64         `json:"seen"`
+	RSSISignalPowerdBm                            float64         `json:"rssi"`
 }
 
 // Wrapper around the SingleAircraftPositionByRegistration API
 func (a ADSBHandlerAnnotator) SingleAircraftPositionByRegistration(reg string) (ac SingleAircraftPosition, err error) {
-	req, err := http.NewRequest("GET", fmt.Sprintf(adsbapiv2, fmt.Sprintf("registration/%s/", reg)), nil)
+	return a.QueryADSBAPI(fmt.Sprintf(a.Provider.Registration, url.PathEscape(reg)))
+}
+
+func (a ADSBHandlerAnnotator) SingleAircraftPositionByHex(hex string) (ac SingleAircraftPosition, err error) {
+	return a.QueryADSBAPI(fmt.Sprintf(a.Provider.Hex, url.PathEscape(strings.ToLower(hex))))
+}
+
+func (a ADSBHandlerAnnotator) SingleAircraftPositionByCallsign(callsign string) (ac SingleAircraftPosition, err error) {
+	return a.QueryADSBAPI(fmt.Sprintf(a.Provider.Callsign, url.PathEscape(strings.ToUpper(callsign))))
+}
+
+func (a ADSBHandlerAnnotator) AircraftPositionsNearPoint(lat, lon float64, radiusNm int) (ac SingleAircraftPosition, err error) {
+	return a.QueryADSBAPI(fmt.Sprintf(a.Provider.Point, lat, lon, radiusNm))
+}
+
+// Makes a request to the configured API and decodes the response
+func (a ADSBHandlerAnnotator) QueryADSBAPI(path string) (ac SingleAircraftPosition, err error) {
+	provider := a.Provider
+	req, err := http.NewRequest("GET", provider.BaseURL+"/"+path, nil)
 	if err != nil {
 		return ac, err
 	}
-	req.Header.Add(adsbapikeyheader, config.ADSBExchangeAPIKey)
-	client := &http.Client{}
+	if provider.KeyHeader != "" && ADSBAPIKey() != "" {
+		req.Header.Add(provider.KeyHeader, ADSBAPIKey())
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
 
-	log.Debug("making call to ads-b exchange")
+	log.Debugf("making call to ads-b api: %s", req.URL)
 	resp, err := client.Do(req)
 	if err != nil {
-		log.Error(err)
+		return ac, err
 	}
 	defer resp.Body.Close()
 	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return ac, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return ac, ADSBAPIError(resp.StatusCode, body)
+	}
 	err = json.Unmarshal(body, &ac)
 	if err != nil {
 		return ac, err
 	}
-
-	if (&ac != &SingleAircraftPosition{}) {
-		log.Debug("returning data from ads-b")
-		return ac, nil
-	} else {
-		return ac, errors.New("unable to parse returned aircraft position")
+	// Some providers answer 200 with the quota message in "msg" and no
+	// aircraft
+	if len(ac.Aircraft) == 0 && ADSBAPIQuotaMessage(ac.Message) {
+		return ac, ADSBAPIError(http.StatusTooManyRequests, body)
 	}
+	log.Debug("returning data from ads-b")
+	return ac, nil
 }
 
-// Interface function to satisfy ACARSHandler
-func (a ADSBHandlerAnnotator) AnnotateACARSMessage(m ACARSMessage) (annotation Annotation) {
-	if config.ADSBExchangeReferenceGeolocation == "" {
-		log.Info("adsb enabled but geolocation not set, using '0,0'")
-		config.ADSBExchangeReferenceGeolocation = "0,0"
+// Turns a failed response into an error, calling out quota and
+// subscription problems since they won't go away on their own
+func ADSBAPIError(status int, body []byte) error {
+	// RapidAPI puts the reason in "message", the aggregators in "msg"
+	var reply struct {
+		Message string `json:"message"`
+		Msg     string `json:"msg"`
 	}
-	coords := strings.Split(config.ADSBExchangeReferenceGeolocation, ",")
-	if len(coords) != 2 {
-		log.Warn("geolocation coordinates are not in the format 'LAT,LON'")
-		return annotation
+	_ = json.Unmarshal(body, &reply)
+	reason := reply.Message
+	if reason == "" {
+		reason = reply.Msg
 	}
-	olat, _ := strconv.ParseFloat(coords[0], 64)
-	olon, _ := strconv.ParseFloat(coords[1], 64)
-	origin := geodist.Coord{Lat: olat, Lon: olon}
-
-	position, err := a.SingleAircraftPositionByRegistration(m.AircraftTailCode)
-	if err != nil {
-		log.Warnf("error getting aircraft position: %v", err)
+	if reason == "" {
+		reason = strings.TrimSpace(string(body))
 	}
-	if len(position.Aircraft) == 0 {
-		log.Warnf("no aircraft were returned from ADS-B API, response message was: %s", position.Message)
-		return annotation
+	switch status {
+	case http.StatusTooManyRequests:
+		return fmt.Errorf("ads-b api quota or rate limit exceeded: %s", reason)
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Errorf("ads-b api rejected the api key: %s", reason)
 	}
+	return fmt.Errorf("ads-b api returned %d: %s", status, reason)
+}
 
-	alat, alon := position.Aircraft[0].Latitude, position.Aircraft[0].Longitude
-	aircraft := geodist.Coord{Lat: alat, Lon: alon}
-	mi, km, err := geodist.VincentyDistance(origin, aircraft)
-	if err != nil {
-		log.Warnf("error calculating distance: %s", err)
+// Reports whether a response message is about quota or subscription limits
+// rather than, ex: "No error"
+func ADSBAPIQuotaMessage(message string) bool {
+	message = strings.ToLower(message)
+	for _, word := range []string{"quota", "exceeded", "rate limit", "too many requests", "subscri"} {
+		if strings.Contains(message, word) {
+			return true
+		}
 	}
-	event := Annotation{
-		"adsbOriginGeolocation":          config.ADSBExchangeReferenceGeolocation,
-		"adsbOriginGeolocationLatitude":  olat,
-		"adsbOriginGeolocationLongitude": olon,
-		"adsbAircraftGeolocation":        fmt.Sprintf("%f,%f", alat, alon),
-		"adsbAircraftLatitude":           alat,
-		"adsbAircraftLongitude":          alon,
-		"adsbAircraftDistanceKm":         km,
-		"adsbAircraftDistanceMi":         mi,
+	return false
+}
+
+// Picks the aircraft with the most recent position when the API returns
+// more than one, ex: a reused callsign or a registration on two
+// transponders
+func (ac SingleAircraftPosition) Freshest() (aircraft ADSBAircraft, found bool) {
+	for _, candidate := range ac.Aircraft {
+		if candidate.Latitude == 0 && candidate.Longitude == 0 {
+			continue
+		}
+		if !found || candidate.SecondsSincePositionUpdated < aircraft.SecondsSincePositionUpdated {
+			aircraft, found = candidate, true
+		}
 	}
+	return aircraft, found
+}
 
-	return event
+// Seconds between the aircraft's last position and when the API answered,
+// counting how long the API had cached its data. Both timestamps come from
+// the API's clock, so a skewed local clock doesn't change the age.
+func (ac SingleAircraftPosition) DataAge(aircraft ADSBAircraft) float64 {
+	age := aircraft.SecondsSincePositionUpdated
+	if ac.APITimestamp > 0 && ac.CacheTime > 0 && ac.CacheTime <= ac.APITimestamp {
+		age += float64(ac.APITimestamp-ac.CacheTime) / 1000
+	}
+	return age
+}
+
+// Looks the aircraft up the way ADSB_API_QUERY says to. hex and callsign
+// can be empty if the message didn't have them.
+func (a ADSBHandlerAnnotator) FindAircraft(origin geodist.Coord, hex, reg, flight string) (ac SingleAircraftPosition, err error) {
+	reg = strings.TrimLeft(reg, ".")
+	callsign := flight
+	if decoded := DecodeFlightNumber(flight); decoded != nil {
+		// ADS-B callsigns use the ICAO form
+		callsign = decoded["flightNumberICAO"].(string)
+	}
+	switch config.ADSBAPIQuery {
+	case ADSBQueryHex:
+		if hex == "" {
+			return ac, errors.New("no icao hex to look up")
+		}
+		return a.SingleAircraftPositionByHex(hex)
+	case ADSBQueryCallsign:
+		if callsign == "" {
+			return ac, errors.New("no callsign to look up")
+		}
+		return a.SingleAircraftPositionByCallsign(callsign)
+	case ADSBQueryPoint:
+		radius := config.ADSBAPIRadiusNm
+		if radius <= 0 {
+			radius = defaultADSBPointRadiusNm
+		}
+		ac, err = a.AircraftPositionsNearPoint(origin.Lat, origin.Lon, radius)
+		if err != nil {
+			return ac, err
+		}
+		// Keep only the aircraft the message is from
+		matching := []ADSBAircraft{}
+		for _, aircraft := range ac.Aircraft {
+			if (hex != "" && strings.EqualFold(aircraft.HexCode, hex)) ||
+				(reg != "" && NormalizeAircraftRegistration(aircraft.AircraftTailCode) == NormalizeAircraftRegistration(reg)) ||
+				(callsign != "" && strings.EqualFold(strings.TrimSpace(aircraft.FlightNumber), callsign)) {
+				matching = append(matching, aircraft)
+			}
+		}
+		ac.Aircraft = matching
+		return ac, nil
+	}
+	if reg == "" {
+		return ac, errors.New("no registration to look up")
+	}
+	return a.SingleAircraftPositionByRegistration(reg)
 }
 
 // Interface function to satisfy ACARSHandler
+func (a ADSBHandlerAnnotator) AnnotateACARSMessage(m ACARSMessage) (annotation Annotation) {
+	record, _ := aircraftDB.LookupRegistration(m.AircraftTailCode)
+	return a.AnnotateAircraft(record.ICAOHex, m.AircraftTailCode, m.FlightNumber)
+}
+
+// Interface function to satisfy VDLM2Handler
 func (a ADSBHandlerAnnotator) AnnotateVDLM2Message(m VDLM2Message) (annotation Annotation) {
+	var hex string
+	if m.VDL2.AVLC.Source.Type == "Aircraft" {
+		hex = m.VDL2.AVLC.Source.Address
+	}
+	return a.AnnotateAircraft(hex, m.VDL2.AVLC.ACARS.Registration, m.VDL2.AVLC.ACARS.FlightNumber)
+}
+
+func (a ADSBHandlerAnnotator) AnnotateAircraft(hex, reg, flight string) (annotation Annotation) {
 	if config.ADSBExchangeReferenceGeolocation == "" {
-		log.Info("adsb exchange enabled but geolocation not set, using '0,0'")
+		log.Info("adsb enabled but geolocation not set, using '0,0'")
 		config.ADSBExchangeReferenceGeolocation = "0,0"
 	}
 	coords := strings.Split(config.ADSBExchangeReferenceGeolocation, ",")
 	if len(coords) != 2 {
-		log.Warn("adsb exchange geolocation coordinates are not in the format 'LAT,LON'")
+		log.Warn("geolocation coordinates are not in the format 'LAT,LON'")
 		return annotation
 	}
 	olat, _ := strconv.ParseFloat(coords[0], 64)
 	olon, _ := strconv.ParseFloat(coords[1], 64)
 	origin := geodist.Coord{Lat: olat, Lon: olon}
 
-	position, err := a.SingleAircraftPositionByRegistration(NormalizeAircraftRegistration(m.VDL2.AVLC.ACARS.Registration))
+	position, err := a.FindAircraft(origin, hex, reg, flight)
 	if err != nil {
-		log.Warnf("error getting aircraft position from adsb exchange: %v", err)
+		log.Warnf("error getting aircraft position from ADS-B API: %v", err)
+		return annotation
 	}
-	if len(position.Aircraft) == 0 {
-		log.Warnf("no aircraft were returned from adsb exchange, response message was: %s", position.Message)
+	found, ok := position.Freshest()
+	if !ok {
+		log.Warnf("no aircraft with a position were returned from ADS-B API, response message was: %s", position.Message)
 		return annotation
 	}
 
-	alat, alon := position.Aircraft[0].Latitude, position.Aircraft[0].Longitude
+	alat, alon := found.Latitude, found.Longitude
 	aircraft := geodist.Coord{Lat: alat, Lon: alon}
 	mi, km, err := geodist.VincentyDistance(origin, aircraft)
 	if err != nil {
 		log.Warnf("error calculating distance: %s", err)
 	}
 	event := Annotation{
-		"adsbOriginGeolocation":          config.ADSBExchangeReferenceGeolocation,
-		"adsbOriginGeolocationLatitude":  olat,
-		"adsbOriginGeolocationLongitude": olon,
-		"adsbAircraftGeolocation":        fmt.Sprintf("%f,%f", alat, alon),
-		"adsbAircraftLatitude":           alat,
-		"adsbAircraftLongitude":          alon,
-		"adsbAircraftDistanceKm":         km,
-		"adsbAircraftDistanceMi":         mi,
+		"adsbOriginGeolocation":                   config.ADSBExchangeReferenceGeolocation,
+		"adsbOriginGeolocationLatitude":           olat,
+		"adsbOriginGeolocationLongitude":          olon,
+		"adsbAircraftGeolocation":                 fmt.Sprintf("%f,%f", alat, alon),
+		"adsbAircraftLatitude":                    alat,
+		"adsbAircraftLongitude":                   alon,
+		"adsbAircraftDistanceKm":                  km,
+		"adsbAircraftDistanceMi":                  mi,
+		"adsbAircraftHex":                         found.HexCode,
+		"adsbAircraftRegistration":                found.AircraftTailCode,
+		"adsbAircraftModel":                       found.AircraftModel,
+		"adsbAircraftFlightNumber":                strings.TrimSpace(found.FlightNumber),
+		"adsbAircraftAltitudeFeet":                found.AltimeterBarometer.Feet,
+		"adsbAircraftOnGround":                    found.AltimeterBarometer.OnGround,
+		"adsbAircraftGeometricAltitudeFeet":       found.AltimeterGeometricFeet,
+		"adsbAircraftVerticalRateFeetPerMinute":   found.AltimeterBarometerRateOfChangeFeet,
+		"adsbAircraftGroundSpeedKnots":            found.GroundSpeedKnots,
+		"adsbAircraftTrackDegrees":                found.TrueGroundTrack,
+		"adsbAircraftSquawk":                      found.Squawk,
+		"adsbAircraftEmergency":                   found.Emergency,
+		"adsbAircraftCategory":                    found.EmitterCategory,
+		"adsbAircraftMessageType":                 found.Type,
+		"adsbAircraftRSSIdBm":                     found.RSSISignalPowerdBm,
+		"adsbAircraftMessageCount":                found.AircraftTotalModeSMessages,
+		"adsbAircraftSecondsSincePositionUpdated": found.SecondsSincePositionUpdated,
+		"adsbAircraftSecondsSinceSeen":            found.SecondsSinceLastMessage,
+		"adsbAircraftResults":                     len(position.Aircraft),
+		"adsbDataAgeSeconds":                      position.DataAge(found),
 	}
 
 	return event
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jftuga/geodist"
+)
+
+func serveADSBAPI(t *testing.T, handler http.HandlerFunc) ADSBHandlerAnnotator {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	previous := config
+	t.Cleanup(func() { config = previous })
+	config.ADSBAPIProvider = "adsb.lol"
+	config.ADSBAPIURL = server.URL + "/v2/"
+	return ADSBHandlerAnnotator{Provider: ADSBAPI()}
+}
+
+func TestADSBAPIError(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   string
+	}{
+		{"rapidapi quota", http.StatusTooManyRequests, `{"message": "You have exceeded the MONTHLY quota"}`, "ads-b api quota or rate limit exceeded: You have exceeded the MONTHLY quota"},
+		{"bad key", http.StatusForbidden, `{"msg": "invalid key"}`, "ads-b api rejected the api key: invalid key"},
+		{"not json", http.StatusBadGateway, " upstream down\n", "ads-b api returned 502: upstream down"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := ADSBAPIError(test.status, []byte(test.body)).Error(); got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestADSBAPIQuotaMessage(t *testing.T) {
+	for message, want := range map[string]bool{
+		"No error": false,
+		"":         false,
+		"You have exceeded the rate limit per hour for your plan": true,
+		"You are not subscribed to this API.":                     true,
+	} {
+		if got := ADSBAPIQuotaMessage(message); got != want {
+			t.Errorf("%q: got %t, want %t", message, got, want)
+		}
+	}
+}
+
+func TestFreshest(t *testing.T) {
+	ac := SingleAircraftPosition{Aircraft: []ADSBAircraft{
+		{HexCode: "nopos", SecondsSincePositionUpdated: 0},
+		{HexCode: "old", Latitude: 40, Longitude: -74, SecondsSincePositionUpdated: 30},
+		{HexCode: "new", Latitude: 41, Longitude: -75, SecondsSincePositionUpdated: 2},
+	}}
+	if aircraft, found := ac.Freshest(); !found || aircraft.HexCode != "new" {
+		t.Errorf("got %q %t, want new", aircraft.HexCode, found)
+	}
+	if _, found := (SingleAircraftPosition{Aircraft: ac.Aircraft[:1]}).Freshest(); found {
+		t.Error("an aircraft without a position was picked")
+	}
+}
+
+func TestADSBDataAge(t *testing.T) {
+	aircraft := ADSBAircraft{SecondsSincePositionUpdated: 2.5}
+	tests := []struct {
+		name string
+		ac   SingleAircraftPosition
+		want float64
+	}{
+		{"no timestamps", SingleAircraftPosition{}, 2.5},
+		// Years in the past by the local clock, which shouldn't matter
+		{"cached", SingleAircraftPosition{APITimestamp: 1000000, CacheTime: 997000}, 5.5},
+		{"cache time after now", SingleAircraftPosition{APITimestamp: 1000000, CacheTime: 1002000}, 2.5},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.ac.DataAge(aircraft); got != test.want {
+				t.Errorf("got %f, want %f", got, test.want)
+			}
+		})
+	}
+}
+
+func TestQueryADSBAPIQuotaOnSuccess(t *testing.T) {
+	a := serveADSBAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ac": [], "msg": "You have exceeded your quota", "now": 1000000}`))
+	})
+	_, err := a.SingleAircraftPositionByHex("A1B2C3")
+	if err == nil || !strings.Contains(err.Error(), "quota") {
+		t.Errorf("got %v, want a quota error", err)
+	}
+}
+
+func TestFindAircraftNearPoint(t *testing.T) {
+	var path string
+	a := serveADSBAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		w.Write([]byte(`{"ac": [
+			{"hex": "a1b2c3", "r": "N12345", "lat": 40, "lon": -74},
+			{"hex": "d4e5f6", "r": "N67890", "flight": "UAL123  ", "lat": 41, "lon": -75},
+			{"hex": "000001", "r": "N11111", "lat": 42, "lon": -76}
+		], "msg": "No error"}`))
+	})
+	config.ADSBAPIQuery = ADSBQueryPoint
+
+	ac, err := a.FindAircraft(geodist.Coord{Lat: 40.5, Lon: -74.5}, "A1B2C3", "", "UA123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != "/v2/point/40.500000/-74.500000/50" {
+		t.Errorf("requested %s", path)
+	}
+	if len(ac.Aircraft) != 2 {
+		t.Errorf("got %d aircraft, want the hex and callsign matches", len(ac.Aircraft))
+	}
+}
+
+func TestADSBAPI(t *testing.T) {
+	previous := config
+	t.Cleanup(func() { config = previous })
+	tests := []struct {
+		name, provider, url, keyHeader string
+		wantBaseURL, wantKeyHeader     string
+		wantHex                        string
+	}{
+		{"default", "", "", "", "https://adsbexchange-com1.p.rapidapi.com/v2", "x-rapidapi-key", "hex/%s/"},
+		{"adsb.fi", "adsb.fi", "", "", "https://opendata.adsb.fi/api/v2", "", "hex/%s"},
+		{"mirror", "adsb.lol", "http://localhost:8080/v2/", "x-api-key", "http://localhost:8080/v2", "x-api-key", "hex/%s"},
+		{"unknown provider", "nope", "", "", "https://api.adsb.lol/v2", "", "hex/%s"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config.ADSBAPIProvider, config.ADSBAPIURL, config.ADSBAPIKeyHeader = test.provider, test.url, test.keyHeader
+			got := ADSBAPI()
+			if got.BaseURL != test.wantBaseURL || got.KeyHeader != test.wantKeyHeader || got.Hex != test.wantHex {
+				t.Errorf("got %+v", got)
+			}
+		})
+	}
+}
+
+func TestFindAircraftQueries(t *testing.T) {
+	var path string
+	a := serveADSBAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		w.Write([]byte(`{"ac": [], "msg": "No error"}`))
+	})
+	tests := []struct {
+		query, hex, reg, flight string
+		wantPath                string
+		wantErr                 bool
+	}{
+		{"", "", ".N12345", "", "/v2/reg/N12345", false},
+		{ADSBQueryRegistration, "", "", "UA123", "", true},
+		{ADSBQueryHex, "A1B2C3", "", "", "/v2/hex/a1b2c3", false},
+		{ADSBQueryHex, "", "N12345", "", "", true},
+		{ADSBQueryCallsign, "", "", "UA123", "/v2/callsign/UAL123", false},
+		{ADSBQueryCallsign, "", "N12345", "", "", true},
+		// Unknown queries look up the registration
+		{"tail", "", "N12345", "", "/v2/reg/N12345", false},
+	}
+	for _, test := range tests {
+		t.Run(test.query+" "+test.wantPath, func(t *testing.T) {
+			path = ""
+			config.ADSBAPIQuery = test.query
+			_, err := a.FindAircraft(geodist.Coord{}, test.hex, test.reg, test.flight)
+			if (err != nil) != test.wantErr || path != test.wantPath {
+				t.Errorf("got %q, error %v", path, err)
+			}
+		})
+	}
+}
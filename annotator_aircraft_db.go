@@ -0,0 +1,60 @@
+package main
+
+import (
+	"strings"
+)
+
+type AircraftDBAnnotator struct {
+}
+
+func (a AircraftDBAnnotator) Name() string {
+	return "aircraft database"
+}
+
+func (a AircraftDBAnnotator) SelectFields(annotation Annotation) Annotation {
+	if config.AircraftDBAnnotatorSelectedFields == "" {
+		return annotation
+	}
+	selectedFields := Annotation{}
+	for field, value := range annotation {
+		if strings.Contains(config.AircraftDBAnnotatorSelectedFields, field) {
+			selectedFields[field] = value
+		}
+	}
+	return selectedFields
+}
+
+// Interface function to satisfy ACARSHandler
+func (a AircraftDBAnnotator) AnnotateACARSMessage(m ACARSMessage) (annotation Annotation) {
+	record, found := aircraftDB.LookupRegistration(m.AircraftTailCode)
+	if !found {
+		return annotation
+	}
+	return AircraftRecordAnnotation(record)
+}
+
+// Interface function to satisfy VDLM2Handler
+func (a AircraftDBAnnotator) AnnotateVDLM2Message(m VDLM2Message) (annotation Annotation) {
+	record, found := aircraftDB.LookupRegistration(m.VDL2.AVLC.ACARS.Registration)
+	if !found && m.VDL2.AVLC.Source.Type == "Aircraft" {
+		// The VDL2 source address is the aircraft's ICAO hex
+		record, found = aircraftDB.LookupHex(m.VDL2.AVLC.Source.Address)
+	}
+	if !found {
+		return annotation
+	}
+	return AircraftRecordAnnotation(record)
+}
+
+func AircraftRecordAnnotation(record AircraftRecord) Annotation {
+	return Annotation{
+		"aircraftDBRegistration":  record.Registration,
+		"aircraftDBICAOHex":       record.ICAOHex,
+		"aircraftDBType":          record.Type,
+		"aircraftDBManufacturer":  record.Manufacturer,
+		"aircraftDBModel":         record.Model,
+		"aircraftDBOwnerOperator": record.OwnerOperator,
+		"aircraftDBYear":          record.Year,
+		"aircraftDBMilitary":      record.Military,
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ex: "UA0123", "BAW45K", "DL12A"
+var (
+	icaoFlightNumberRegex = regexp.MustCompile(`^([A-Z]{3})(\d[0-9A-Z]{0,4})$`)
+	iataFlightNumberRegex = regexp.MustCompile(`^([A-Z0-9]{2})(\d{1,4}[A-Z]?)$`)
+)
+
+type AirlineAnnotator struct {
+}
+
+func (a AirlineAnnotator) Name() string {
+	return "airline"
+}
+
+func (a AirlineAnnotator) SelectFields(annotation Annotation) Annotation {
+	if config.AirlineAnnotatorSelectedFields == "" {
+		return annotation
+	}
+	selectedFields := Annotation{}
+	for field, value := range annotation {
+		if strings.Contains(config.AirlineAnnotatorSelectedFields, field) {
+			selectedFields[field] = value
+		}
+	}
+	return selectedFields
+}
+
+// Interface function to satisfy ACARSHandler
+func (a AirlineAnnotator) AnnotateACARSMessage(m ACARSMessage) (annotation Annotation) {
+	return DecodeFlightNumber(m.FlightNumber)
+}
+
+// Interface function to satisfy VDLM2Handler
+func (a AirlineAnnotator) AnnotateVDLM2Message(m VDLM2Message) (annotation Annotation) {
+	return DecodeFlightNumber(m.VDL2.AVLC.ACARS.FlightNumber)
+}
+
+// Looks up the airline for an ACARS flight ID and gives the flight number in
+// both IATA ("UA123") and ICAO ("UAL123") forms. Returns nil if the airline
+// isn't known.
+func DecodeFlightNumber(flightID string) (annotation Annotation) {
+	flightID = strings.ToUpper(strings.TrimSpace(flightID))
+	var airline Airline
+	var number string
+	// ICAO callsigns can be alphanumeric ("BAW45K"), which doesn't map back
+	// to an IATA flight number
+	hasIATANumber := true
+	if match := icaoFlightNumberRegex.FindStringSubmatch(flightID); match != nil {
+		if found, ok := airlinesByICAO[match[1]]; ok {
+			airline, number = found, match[2]
+			hasIATANumber = strings.Trim(number, "0123456789") == ""
+		}
+	}
+	if airline.Name == "" {
+		if match := iataFlightNumberRegex.FindStringSubmatch(flightID); match != nil {
+			if found, ok := airlinesByIATA[match[1]]; ok {
+				airline, number = found, match[2]
+				hasIATANumber = true
+			}
+		}
+	}
+	if airline.Name == "" {
+		return nil
+	}
+	// ACARS pads flight numbers to four digits ("UA0123")
+	number = strings.TrimLeft(number, "0")
+	if number == "" || number[0] < '0' || number[0] > '9' {
+		number = "0" + number
+	}
+	annotation = Annotation{
+		"airlineName":      airline.Name,
+		"airlineIATA":      airline.IATA,
+		"airlineICAO":      airline.ICAO,
+		"airlineCountry":   airline.Country,
+		"airlineCallsign":  airline.Callsign,
+		"flightNumberICAO": airline.ICAO + number,
+		"flightCallsign":   airline.Callsign + " " + number,
+	}
+	if hasIATANumber && airline.IATA != "" {
+		annotation["flightNumberIATA"] = airline.IATA + number
+	}
+	return annotation
+}
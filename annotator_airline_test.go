@@ -0,0 +1,34 @@
+package main
+
+import "testing"
+
+func TestDecodeFlightNumber(t *testing.T) {
+	tests := []struct {
+		flightID string
+		want     Annotation
+	}{
+		{"UA0123", Annotation{"airlineICAO": "UAL", "flightNumberIATA": "UA123", "flightNumberICAO": "UAL123", "flightCallsign": "UNITED 123"}},
+		{"UAL123", Annotation{"airlineIATA": "UA", "flightNumberIATA": "UA123", "flightNumberICAO": "UAL123"}},
+		{" dl12a ", Annotation{"flightNumberIATA": "DL12A", "flightNumberICAO": "DAL12A"}},
+		{"UA0000", Annotation{"flightNumberIATA": "UA0", "flightNumberICAO": "UAL0"}},
+		// Alphanumeric ICAO callsigns have no IATA flight number
+		{"BAW45K", Annotation{"airlineName": "British Airways", "flightNumberICAO": "BAW45K", "flightCallsign": "SPEEDBIRD 45K", "flightNumberIATA": nil}},
+		{"BAW0AB", Annotation{"flightNumberICAO": "BAW0AB", "flightNumberIATA": nil}},
+		{"ZZZ123", nil},
+		{"", nil},
+	}
+	for _, test := range tests {
+		got := DecodeFlightNumber(test.flightID)
+		if test.want == nil {
+			if got != nil {
+				t.Errorf("%q: got %v, want nothing", test.flightID, got)
+			}
+			continue
+		}
+		for field, want := range test.want {
+			if got[field] != want {
+				t.Errorf("%q: %s is %v, want %v", test.flightID, field, got[field], want)
+			}
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"strings"
+)
+
+type AirportAnnotator struct {
+}
+
+func (a AirportAnnotator) Name() string {
+	return "airport"
+}
+
+func (a AirportAnnotator) SelectFields(annotation Annotation) Annotation {
+	if config.AirportAnnotatorSelectedFields == "" {
+		return annotation
+	}
+	selectedFields := Annotation{}
+	for field, value := range annotation {
+		if strings.Contains(config.AirportAnnotatorSelectedFields, field) {
+			selectedFields[field] = value
+		}
+	}
+	return selectedFields
+}
+
+// Interface function to satisfy ACARSHandler
+func (a AirportAnnotator) AnnotateACARSMessage(m ACARSMessage) (annotation Annotation) {
+	return a.AnnotateAirports(ACARSMessageAircraft(m))
+}
+
+// Interface function to satisfy VDLM2Handler
+func (a AirportAnnotator) AnnotateVDLM2Message(m VDLM2Message) (annotation Annotation) {
+	return a.AnnotateAirports(VDLM2MessageAircraft(m))
+}
+
+// Resolves the route in the message text and finds the airport nearest the
+// aircraft
+func (a AirportAnnotator) AnnotateAirports(aircraft MessageAircraft) (annotation Annotation) {
+	annotation = Annotation{}
+	parsed := ParseMessageText(aircraft.Label, aircraft.Text)
+	for field, prefix := range map[string]string{
+		"parsedOrigin":      "routeOrigin",
+		"parsedDestination": "routeDestination",
+	} {
+		code, _ := parsed[field].(string)
+		if airport, found := airportDB.Lookup(code); found {
+			annotation = MergeMaps(annotation, AirportFields(prefix, airport))
+		}
+	}
+
+	if point, _, found := aircraft.Position().Point(); found {
+		if airport, km, found := airportDB.Nearest(point.Latitude, point.Longitude); found {
+			annotation = MergeMaps(annotation, AirportFields("nearestAirport", airport))
+			annotation["nearestAirportDistanceKm"] = km
+			annotation["nearestAirportDistanceNm"] = km / kmPerNauticalMile
+		}
+	}
+	if len(annotation) == 0 {
+		return nil
+	}
+	return annotation
+}
+
+// ex: prefix "routeOrigin" gives "routeOriginICAO", "routeOriginName", etc.
+func AirportFields(prefix string, airport Airport) Annotation {
+	return Annotation{
+		prefix + "ICAO":      airport.ICAO,
+		prefix + "IATA":      airport.IATA,
+		prefix + "Name":      airport.Name,
+		prefix + "City":      airport.City,
+		prefix + "Country":   airport.Country,
+		prefix + "Latitude":  airport.Latitude,
+		prefix + "Longitude": airport.Longitude,
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ARINC 622 wraps binary ATS applications (FANS-1/A CPDLC and ADS-C) in ACARS
+// text like this:
+//
+//	/BOMASAI.ADS.VT-ANB072501A070A988CA73248F0E5DC10200000F5EE1ABC000102B885E0A19F5
+//
+// which is the ground station address, the imbedded message identifier (IMI),
+// the registration left padded with dots to 7 characters (".VT-ANB"), then
+// the hex encoded application message followed by a 16 bit CRC.
+var arinc622Regex = regexp.MustCompile(`/([A-Z0-9]{7})\.([A-Z0-9]{3})([A-Z0-9.\-]{7})([0-9A-F]*)`)
+
+// What each imbedded message identifier carries
+var ARINC622IMIs = map[string]struct {
+	Application string
+	Description string
+}{
+	"AT1": {"CPDLC", "FANS-1/A CPDLC message"},
+	"CR1": {"CPDLC", "FANS-1/A CPDLC connect request"},
+	"CC1": {"CPDLC", "FANS-1/A CPDLC connect confirm"},
+	"DR1": {"CPDLC", "FANS-1/A CPDLC disconnect request"},
+	"ADS": {"ADS-C", "ADS-C message"},
+	"DIS": {"ADS-C", "ADS-C disconnect request"},
+}
+
+type ARINC622Annotator struct {
+}
+
+func (a ARINC622Annotator) Name() string {
+	return "arinc 622"
+}
+
+func (a ARINC622Annotator) SelectFields(annotation Annotation) Annotation {
+	if config.ARINC622AnnotatorSelectedFields == "" {
+		return annotation
+	}
+	selectedFields := Annotation{}
+	for field, value := range annotation {
+		if strings.Contains(config.ARINC622AnnotatorSelectedFields, field) {
+			selectedFields[field] = value
+		}
+	}
+	return selectedFields
+}
+
+// Interface function to satisfy ACARSHandler
+func (a ARINC622Annotator) AnnotateACARSMessage(m ACARSMessage) (annotation Annotation) {
+	info, _ := LookupACARSLabel(m.Label)
+	return DecodeARINC622(m.MessageText, ACARSDirection(m.BlockID, info))
+}
+
+// Interface function to satisfy VDLM2Handler
+func (a ARINC622Annotator) AnnotateVDLM2Message(m VDLM2Message) (annotation Annotation) {
+	info, _ := LookupACARSLabel(m.VDL2.AVLC.ACARS.Label)
+	return DecodeARINC622(m.VDL2.AVLC.ACARS.MessageText, ACARSDirection(m.VDL2.AVLC.ACARS.BlockID, info))
+}
+
+// Decodes an ARINC 622 message found anywhere in the text, returning nil if
+// there isn't one
+func DecodeARINC622(text, direction string) (annotation Annotation) {
+	match := arinc622Regex.FindStringSubmatch(text)
+	if match == nil {
+		return nil
+	}
+	groundAddress, imi, registration, payloadHex := match[1], match[2], match[3], match[4]
+	imiInfo, known := ARINC622IMIs[imi]
+	if !known {
+		return nil
+	}
+	annotation = Annotation{
+		"arinc622GroundAddress":  groundAddress,
+		"arinc622IMI":            imi,
+		"arinc622IMIDescription": imiInfo.Description,
+		"arinc622Application":    imiInfo.Application,
+		"arinc622Registration":   strings.TrimLeft(registration, "."),
+	}
+	if len(payloadHex)%2 != 0 || len(payloadHex) < 4 {
+		annotation["arinc622CRCOK"] = false
+		return annotation
+	}
+	payload, err := hex.DecodeString(payloadHex)
+	if err != nil {
+		annotation["arinc622CRCOK"] = false
+		return annotation
+	}
+	crcOK := ARINC622CRCOK(imi+registration, payload)
+	annotation["arinc622CRCOK"] = crcOK
+	if !crcOK {
+		// Don't decode garbage
+		return annotation
+	}
+	payload = payload[:len(payload)-2]
+
+	var decoded Annotation
+	switch imiInfo.Application {
+	case "ADS-C":
+		if imi == "DIS" {
+			decoded = Annotation{"arinc622DecodedText": "ADS-C disconnect request"}
+		} else if direction == ACARSDirectionUplink {
+			decoded = Annotation{"arinc622DecodedText": "ADS-C contract request"}
+		} else {
+			decoded, err = DecodeADSC(payload)
+		}
+	case "CPDLC":
+		decoded, err = DecodeCPDLC(payload, direction)
+		if decoded != nil && imi != "AT1" {
+			decoded["arinc622DecodedText"] = imiInfo.Description + ": " + fmt.Sprint(decoded["arinc622DecodedText"])
+		}
+	}
+	if err != nil {
+		annotation["arinc622DecodeError"] = err.Error()
+	}
+	return MergeMaps(annotation, decoded)
+}
+
+// The CRC is sent inverted, so running the CRC over the message and the CRC
+// together always leaves this
+const arinc622CRCResidue = 0x1D0F
+
+// Checks the CRC that ends the payload. It covers the IMI, registration and
+// application message and is CRC-16/CCITT inverted and sent most significant
+// byte first (CRC-16/GENIBUS), which libacars checks by its residue too.
+func ARINC622CRCOK(header string, payload []byte) bool {
+	if len(payload) < 2 {
+		return false
+	}
+	return crc16CCITT(append([]byte(header), payload...)) == arinc622CRCResidue
+}
+
+// CRC-16/CCITT-FALSE: polynomial 0x1021, initial 0xFFFF, most significant bit
+// first and not inverted
+func crc16CCITT(data []byte) uint16 {
+	crc := uint16(0xFFFF)
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+var errBitReaderShort = errors.New("message ended early")
+
+// Reads big-endian bit fields out of a byte slice
+type bitReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *bitReader) remaining() int {
+	return len(r.data)*8 - r.pos
+}
+
+func (r *bitReader) read(n int) (uint64, error) {
+	if n > r.remaining() {
+		return 0, errBitReaderShort
+	}
+	var value uint64
+	for i := 0; i < n; i++ {
+		bit := r.data[r.pos/8] >> (7 - r.pos%8) & 1
+		value = value<<1 | uint64(bit)
+		r.pos++
+	}
+	return value, nil
+}
+
+// Reads an n bit two's complement number
+func (r *bitReader) readSigned(n int) (int64, error) {
+	value, err := r.read(n)
+	if err != nil {
+		return 0, err
+	}
+	if value&(1<<(n-1)) != 0 {
+		return int64(value) - int64(1)<<n, nil
+	}
+	return int64(value), nil
+}
+
+// Skips to the start of the next byte
+func (r *bitReader) align() {
+	if r.pos%8 != 0 {
+		r.pos += 8 - r.pos%8
+	}
+}
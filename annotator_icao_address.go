@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+type ICAOAddressAnnotator struct {
+}
+
+func (a ICAOAddressAnnotator) Name() string {
+	return "icao address"
+}
+
+func (a ICAOAddressAnnotator) SelectFields(annotation Annotation) Annotation {
+	if config.ICAOAddressAnnotatorSelectedFields == "" {
+		return annotation
+	}
+	selectedFields := Annotation{}
+	for field, value := range annotation {
+		if strings.Contains(config.ICAOAddressAnnotatorSelectedFields, field) {
+			selectedFields[field] = value
+		}
+	}
+	return selectedFields
+}
+
+// Interface function to satisfy ACARSHandler
+func (a ICAOAddressAnnotator) AnnotateACARSMessage(m ACARSMessage) (annotation Annotation) {
+	// ACARS doesn't carry the address so it has to come from the database.
+	// The database found it by registration, so checking the two against
+	// each other wouldn't tell us anything.
+	record, found := aircraftDB.LookupRegistration(m.AircraftTailCode)
+	if !found {
+		return annotation
+	}
+	return DecodeICAOAddress(record.ICAOHex, "")
+}
+
+// Interface function to satisfy VDLM2Handler
+func (a ICAOAddressAnnotator) AnnotateVDLM2Message(m VDLM2Message) (annotation Annotation) {
+	avlc := m.VDL2.AVLC
+	switch {
+	case avlc.Source.Type == "Aircraft":
+		// The address the aircraft transmitted, so it's worth checking
+		// against the registration it claims
+		return DecodeICAOAddress(avlc.Source.Address, avlc.ACARS.Registration)
+	case avlc.Destination.Type == "Aircraft":
+		return DecodeICAOAddress(avlc.Destination.Address, "")
+	}
+	return annotation
+}
+
+// Works out who allocated the address and, if given a registration, checks
+// it agrees
+func DecodeICAOAddress(hex, registration string) (annotation Annotation) {
+	address, ok := ParseICAOAddress(hex)
+	if !ok {
+		return nil
+	}
+	military := ICAOAddressMilitary(address)
+	annotation = Annotation{
+		"icaoAddress":         fmt.Sprintf("%06X", address),
+		"icaoAddressMilitary": military,
+	}
+	country, found := ICAOAddressCountry(address)
+	if !found {
+		annotation["icaoAddressWarning"] = "address is not in any allocated block"
+		return annotation
+	}
+	annotation["icaoAddressCountry"] = country
+
+	// Military aircraft often use serials rather than registrations
+	if military {
+		return annotation
+	}
+	if matches, ok := RegistrationMatchesCountry(registration, country); ok {
+		annotation["icaoAddressRegistrationMismatch"] = !matches
+		if !matches {
+			annotation["icaoAddressWarning"] = fmt.Sprintf("registration %s doesn't match address country %s",
+				strings.TrimLeft(registration, "."), country)
+		}
+	}
+	return annotation
+}
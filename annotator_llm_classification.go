@@ -0,0 +1,267 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	api "github.com/ollama/ollama/api"
+	"github.com/openai/openai-go"
+	log "github.com/sirupsen/logrus"
+)
+
+// The fixed set of categories messages are sorted into
+var LLMClassificationCategories = []string{
+	"maintenance",
+	"weather",
+	"ops/delay",
+	"medical",
+	"security",
+	"crew chatter",
+	"automated telemetry",
+}
+
+// Anything the model makes up outside the list above, so the category
+// metrics stay a fixed set
+const LLMClassificationOther = "other"
+
+var LLMClassificationPrompt string = `You will classify an ACARS message sent
+to or from an aircraft.
+Your entire response MUST BE A VALID JSON OBJECT with the format:
+
+{"category": string, "summary": string, "confidence": number, "expandedText": string}
+
+"category" MUST be exactly one of: %s
+"summary" should be a single line of plain English describing the message.
+"confidence" should be how sure you are of the category, from 0 to 1.
+"expandedText" should be the message with aviation abbreviations expanded.
+Do not use backticks.
+
+Here is the message:
+%s
+`
+
+var LLMClassificationSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"category":     map[string]any{"type": "string", "enum": LLMClassificationCategories},
+		"summary":      map[string]any{"type": "string"},
+		"confidence":   map[string]any{"type": "number"},
+		"expandedText": map[string]any{"type": "string"},
+	},
+	"required":             []string{"category", "summary", "confidence", "expandedText"},
+	"additionalProperties": false,
+}
+
+type LLMClassificationResponse struct {
+	Category     string  `json:"category"`
+	Summary      string  `json:"summary"`
+	Confidence   float64 `json:"confidence"`
+	ExpandedText string  `json:"expandedText"`
+}
+
+type LLMClassificationAnnotator struct {
+}
+
+func (a LLMClassificationAnnotator) Name() string {
+	return "llm classification"
+}
+
+func (a LLMClassificationAnnotator) SelectFields(annotation Annotation) Annotation {
+	if config.LLMAnnotatorSelectedFields == "" {
+		return annotation
+	}
+	selectedFields := Annotation{}
+	for field, value := range annotation {
+		if strings.Contains(config.LLMAnnotatorSelectedFields, field) {
+			selectedFields[field] = value
+		}
+	}
+	return selectedFields
+}
+
+// Interface function to satisfy ACARSHandler
+func (a LLMClassificationAnnotator) AnnotateACARSMessage(m ACARSMessage) (annotation Annotation) {
+	return a.Classify(m.MessageText)
+}
+
+// Interface function to satisfy VDLM2Handler
+func (a LLMClassificationAnnotator) AnnotateVDLM2Message(m VDLM2Message) (annotation Annotation) {
+	return a.Classify(m.VDL2.AVLC.ACARS.MessageText)
+}
+
+// Returns the provider to use, OpenAI if it's configured and Ollama otherwise
+func LLMClassificationProvider() string {
+	if config.LLMClassificationProvider != "" {
+		return strings.ToLower(config.LLMClassificationProvider)
+	}
+	if config.OpenAIAPIKey != "" || config.OpenAIBaseURL != "" {
+		return "openai"
+	}
+	return "ollama"
+}
+
+// Asks the configured LLM to classify message text
+func (a LLMClassificationAnnotator) Classify(text string) (annotation Annotation) {
+	if blankMessageRegex.MatchString(text) {
+		log.Debug("message has no text, not classifying it")
+		return annotation
+	}
+	prompt := fmt.Sprintf(LLMClassificationPrompt, strings.Join(LLMClassificationCategories, ", "), text)
+
+	provider := LLMClassificationProvider()
+	limiter, model := openAILimiter, OpenAIModel()
+	chat := classifyWithOpenAI
+	if provider == "ollama" {
+		limiter, model = ollamaLimiter, config.OllamaModel
+		chat = classifyWithOllama
+	}
+	key := LLMCacheKey(provider+" classification", model, LLMClassificationPrompt, text)
+	if llmCache != nil {
+		if r, ok := llmCache.GetClassification(key); ok {
+			metrics.Increment("llmcache." + provider + ".classification.hit")
+			return r.Annotation()
+		}
+		metrics.Increment("llmcache." + provider + ".classification.miss")
+	}
+
+	for attempt := 0; attempt <= LLMMalformedRetries(); attempt++ {
+		// Every attempt is a call, so each one is charged
+		if !limiter.Call() {
+			return annotation
+		}
+		content, err := chat(prompt)
+		if err != nil {
+			log.Errorf("error classifying message with %s: %s", provider, err)
+			return annotation
+		}
+		var r LLMClassificationResponse
+		if err = ParseLLMJSON(content, &r); err != nil || r.Category == "" {
+			log.Warnf("error unmarshaling classification from %s (attempt %d): %v", provider, attempt+1, err)
+			continue
+		}
+		if !slices.Contains(LLMClassificationCategories, r.Category) {
+			log.Debugf("%s classified a message as unknown category %q", provider, r.Category)
+			r.Category = LLMClassificationOther
+		}
+		metrics.Increment("llm.classification." + r.Category)
+		if llmCache != nil {
+			llmCache.PutClassification(key, r)
+		}
+		return r.Annotation()
+	}
+	return annotation
+}
+
+func (r LLMClassificationResponse) Annotation() Annotation {
+	return Annotation{
+		"llmCategory":     r.Category,
+		"llmSummary":      r.Summary,
+		"llmConfidence":   r.Confidence,
+		"llmExpandedText": r.ExpandedText,
+	}
+}
+
+func classifyWithOpenAI(prompt string) (string, error) {
+	client := NewOpenAIClient()
+	ctx := context.Background()
+	if config.OpenAITimeoutSeconds > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(config.OpenAITimeoutSeconds)*time.Second)
+		defer cancel()
+	}
+	params := openai.ChatCompletionNewParams{
+		Messages: openai.F([]openai.ChatCompletionMessageParamUnion{
+			openai.UserMessage(prompt),
+		}),
+		Model: openai.F(OpenAIModel()),
+	}
+	if format := classificationResponseFormat(); format != nil {
+		params.ResponseFormat = openai.F(format)
+	}
+	chatCompletion, err := client.Chat.Completions.New(ctx, params)
+	for OpenAIResponseFormatRejected(err) && FallBackOpenAIResponseFormat() {
+		if !openAILimiter.Call() {
+			return "", errors.New("over the limit retrying with a looser response format")
+		}
+		params.ResponseFormat = openai.Null[openai.ChatCompletionNewParamsResponseFormatUnion]()
+		if format := classificationResponseFormat(); format != nil {
+			params.ResponseFormat = openai.F(format)
+		}
+		chatCompletion, err = client.Chat.Completions.New(ctx, params)
+	}
+	if err != nil {
+		return "", err
+	}
+	openAILimiter.Record(chatCompletion.Usage.PromptTokens, chatCompletion.Usage.CompletionTokens)
+	if len(chatCompletion.Choices) == 0 {
+		return "", errors.New("OpenAI returned no choices")
+	}
+	log.Debugf("classification from OpenAI: %s", chatCompletion.Choices[0].Message.Content)
+	return chatCompletion.Choices[0].Message.Content, nil
+}
+
+// Same as the filter's response format, but with our own schema
+func classificationResponseFormat() openai.ChatCompletionNewParamsResponseFormatUnion {
+	format := OpenAIResponseFormat()
+	if _, ok := format.(openai.ResponseFormatJSONSchemaParam); ok {
+		return openai.ResponseFormatJSONSchemaParam{
+			Type: openai.F(openai.ResponseFormatJSONSchemaTypeJSONSchema),
+			JSONSchema: openai.F(openai.ResponseFormatJSONSchemaJSONSchemaParam{
+				Name:   openai.F("message_classification"),
+				Schema: openai.F[any](LLMClassificationSchema),
+				Strict: openai.F(true),
+			}),
+		}
+	}
+	return format
+}
+
+func classifyWithOllama(prompt string) (string, error) {
+	if config.OllamaModel == "" {
+		return "", errors.New("Ollama model not specified")
+	}
+	url, err := url.Parse(config.OllamaURL)
+	if err != nil {
+		return "", err
+	}
+	client := api.NewClient(url, &http.Client{})
+	ctx := context.Background()
+	if config.OllamaTimeoutSeconds > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(config.OllamaTimeoutSeconds)*time.Second)
+		defer cancel()
+	}
+	format := OllamaFormat()
+	if strings.ToLower(config.OllamaFormat) == "schema" {
+		format, _ = json.Marshal(LLMClassificationSchema)
+	}
+	stream := false
+	req := &api.ChatRequest{
+		Model: config.OllamaModel,
+		Messages: []api.Message{
+			{
+				Role:    "user",
+				Content: prompt,
+			},
+		},
+		Stream: &stream,
+		Format: format,
+	}
+	var content string
+	err = client.Chat(ctx, req, func(resp api.ChatResponse) error {
+		content += resp.Message.Content
+		if resp.Done {
+			ollamaLimiter.Record(int64(resp.PromptEvalCount), int64(resp.EvalCount))
+		}
+		return nil
+	})
+	log.Debugf("classification from Ollama: %s", content)
+	return content, err
+}
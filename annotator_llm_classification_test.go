@@ -0,0 +1,75 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// An OpenAI-compatible server that answers each request with the next reply
+func serveClassifications(t *testing.T, replies ...string) (requests *int) {
+	requests = new(int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reply := replies[min(*requests, len(replies)-1)]
+		*requests++
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id": "1", "object": "chat.completion", "created": 0, "model": "local", "choices": [{"index": 0, "finish_reason": "stop", "message": {"role": "assistant", "content": ` + strconv.Quote(reply) + `}}], "usage": {"prompt_tokens": 10, "completion_tokens": 5, "total_tokens": 15}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	previousConfig, previousCache, previousLimiter := config, llmCache, openAILimiter
+	t.Cleanup(func() {
+		config, llmCache, openAILimiter = previousConfig, previousCache, previousLimiter
+	})
+	config.OpenAIBaseURL = server.URL + "/v1"
+	config.OpenAIAPIKey = "test"
+	config.OpenAIModel = "local"
+	config.OpenAIResponseFormat = "json_object"
+	config.LLMClassificationProvider = "openai"
+	llmCache, openAILimiter = nil, nil
+	return requests
+}
+
+func TestClassifyCategories(t *testing.T) {
+	tests := []struct {
+		reply string
+		want  string
+	}{
+		{`{"category": "medical", "summary": "passenger ill", "confidence": 0.9, "expandedText": ""}`, "medical"},
+		{`{"category": "fuel planning", "summary": "fuel", "confidence": 0.5, "expandedText": ""}`, LLMClassificationOther},
+	}
+	for _, test := range tests {
+		serveClassifications(t, test.reply)
+		annotation := LLMClassificationAnnotator{}.Classify("REQUEST MEDICAL ASSISTANCE ON ARRIVAL")
+		if annotation["llmCategory"] != test.want {
+			t.Errorf("got %v, want %q", annotation["llmCategory"], test.want)
+		}
+	}
+}
+
+func TestClassifyCached(t *testing.T) {
+	requests := serveClassifications(t, `{"category": "weather", "summary": "metar", "confidence": 1, "expandedText": ""}`)
+	llmCache = NewLLMCache(10, time.Hour)
+	for i := 0; i < 3; i++ {
+		if annotation := (LLMClassificationAnnotator{}).Classify("METAR KJFK 011200Z"); annotation["llmCategory"] != "weather" {
+			t.Fatalf("got %v", annotation)
+		}
+	}
+	if *requests != 1 {
+		t.Errorf("server saw %d requests, want 1", *requests)
+	}
+}
+
+func TestClassifyChargesRetries(t *testing.T) {
+	requests := serveClassifications(t, "not json", `{"category": "weather", "summary": "", "confidence": 1, "expandedText": ""}`)
+	// One call a minute, so the retry after the malformed reply is limited
+	openAILimiter = NewLLMLimiter("openai", 1, 0, 0, 0, 0)
+	if annotation := (LLMClassificationAnnotator{}).Classify("METAR KJFK 011200Z"); annotation != nil {
+		t.Errorf("got %v, want nothing", annotation)
+	}
+	if *requests != 1 {
+		t.Errorf("server saw %d requests, want 1", *requests)
+	}
+}
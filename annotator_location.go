@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+type LocationAnnotator struct {
+}
+
+func (a LocationAnnotator) Name() string {
+	return "location"
+}
+
+func (a LocationAnnotator) SelectFields(annotation Annotation) Annotation {
+	if config.LocationAnnotatorSelectedFields == "" {
+		return annotation
+	}
+	selectedFields := Annotation{}
+	for field, value := range annotation {
+		if strings.Contains(config.LocationAnnotatorSelectedFields, field) {
+			selectedFields[field] = value
+		}
+	}
+	return selectedFields
+}
+
+// Interface function to satisfy ACARSHandler
+func (a LocationAnnotator) AnnotateACARSMessage(m ACARSMessage) (annotation Annotation) {
+	return a.AnnotateLocation(ACARSMessageAircraft(m))
+}
+
+// Interface function to satisfy VDLM2Handler
+func (a LocationAnnotator) AnnotateVDLM2Message(m VDLM2Message) (annotation Annotation) {
+	return a.AnnotateLocation(VDLM2MessageAircraft(m))
+}
+
+func (a LocationAnnotator) AnnotateLocation(aircraft MessageAircraft) (annotation Annotation) {
+	point, _, found := aircraft.Position().Point()
+	if !found {
+		return nil
+	}
+	return DescribeLocation(point.Latitude, point.Longitude)
+}
+
+// Describes a position relative to the nearest city, ex: "12 nm NE of
+// Denver, CO, US"
+func DescribeLocation(lat, lon float64) (annotation Annotation) {
+	if locationDB == nil {
+		return nil
+	}
+	annotation = Annotation{}
+	city, km, cityFound := locationDB.NearestCity(lat, lon)
+
+	// Boundaries say what the aircraft is over. Without them, the nearest
+	// city's country is the best guess.
+	countryCode := city.CountryCode
+	if len(locationDB.boundaries) > 0 {
+		countryCode = ""
+		country, over := locationDB.Country(lat, lon)
+		annotation["locationOverWater"] = !over
+		if over {
+			countryCode = country.CountryCode
+			annotation["locationCountry"] = country.Name
+		}
+	}
+	if countryCode != "" {
+		annotation["locationCountryCode"] = countryCode
+		// Prefer GeoNames' name so it matches with or without boundaries
+		if name, ok := locationDB.countries[countryCode]; ok {
+			annotation["locationCountry"] = name
+		} else if _, ok := annotation["locationCountry"]; !ok {
+			annotation["locationCountry"] = countryCode
+		}
+	}
+	if admin, over := locationDB.Admin(lat, lon); over {
+		annotation["locationAdmin"] = admin.Name
+		annotation["locationAdminCode"] = admin.Code
+	}
+
+	if !cityFound {
+		if country, ok := annotation["locationCountry"].(string); ok {
+			annotation["locationDescription"] = "over " + country
+		}
+		if len(annotation) == 0 {
+			return nil
+		}
+		return annotation
+	}
+	place := []string{city.Name}
+	if admin := locationDB.AdminName(city); admin != "" {
+		place = append(place, admin)
+	}
+	place = append(place, city.CountryCode)
+	cityName := strings.Join(place, ", ")
+
+	nm := km / kmPerNauticalMile
+	cardinal := CardinalDirection(Bearing(city.Latitude, city.Longitude, lat, lon))
+	description := "over " + cityName
+	if km >= locationOverCityKm {
+		description = fmt.Sprintf("%.0f nm %s of %s", math.Round(nm), cardinal, cityName)
+	}
+	annotation["locationDescription"] = description
+	annotation["locationNearestCity"] = cityName
+	annotation["locationNearestCityDistanceKm"] = km
+	annotation["locationNearestCityDistanceNm"] = nm
+	annotation["locationNearestCityCardinal"] = cardinal
+	return annotation
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/jftuga/geodist"
+	log "github.com/sirupsen/logrus"
+)
+
+type LookAngleAnnotator struct {
+}
+
+func (a LookAngleAnnotator) Name() string {
+	return "look angle"
+}
+
+func (a LookAngleAnnotator) SelectFields(annotation Annotation) Annotation {
+	if config.LookAngleAnnotatorSelectedFields == "" {
+		return annotation
+	}
+	selectedFields := Annotation{}
+	for field, value := range annotation {
+		if strings.Contains(config.LookAngleAnnotatorSelectedFields, field) {
+			selectedFields[field] = value
+		}
+	}
+	return selectedFields
+}
+
+// Interface function to satisfy ACARSHandler
+func (a LookAngleAnnotator) AnnotateACARSMessage(m ACARSMessage) (annotation Annotation) {
+	return a.AnnotateLookAngle(ACARSMessageAircraft(m))
+}
+
+// Interface function to satisfy VDLM2Handler
+func (a LookAngleAnnotator) AnnotateVDLM2Message(m VDLM2Message) (annotation Annotation) {
+	return a.AnnotateLookAngle(VDLM2MessageAircraft(m))
+}
+
+// Works out where to look from the station to see the aircraft
+func (a LookAngleAnnotator) AnnotateLookAngle(aircraft MessageAircraft) (annotation Annotation) {
+	station, ok := StationLocation()
+	if !ok {
+		log.Warn("look angle annotator enabled but STATION_GEOLOCATION is not in the format 'LAT,LON'")
+		return nil
+	}
+	point, source, found := aircraft.Position().Point()
+	if !found {
+		return nil
+	}
+
+	_, km, err := geodist.VincentyDistance(
+		geodist.Coord{Lat: station.Latitude, Lon: station.Longitude},
+		geodist.Coord{Lat: point.Latitude, Lon: point.Longitude},
+	)
+	if err != nil {
+		// Vincenty doesn't converge for nearly antipodal points
+		_, km = geodist.HaversineDistance(
+			geodist.Coord{Lat: station.Latitude, Lon: station.Longitude},
+			geodist.Coord{Lat: point.Latitude, Lon: point.Longitude},
+		)
+	}
+	bearing := Bearing(station.Latitude, station.Longitude, point.Latitude, point.Longitude)
+	annotation = Annotation{
+		"lookAngleBearingDegrees":   bearing,
+		"lookAngleCardinal":         CardinalDirection(bearing),
+		"lookAngleGroundDistanceKm": km,
+		"lookAngleGroundDistanceNm": km / kmPerNauticalMile,
+		"lookAnglePositionSource":   source,
+	}
+
+	// Without an altitude the rest would be a guess
+	if !point.HasAltitude && !point.OnGround {
+		return annotation
+	}
+	altitudeMeters := float64(point.AltitudeFeet) * metersPerFoot
+	if point.OnGround {
+		// Close enough for an aircraft on the ground near the station
+		altitudeMeters = station.GroundMeters
+	} else {
+		annotation["lookAngleAircraftAltitudeFeet"] = point.AltitudeFeet
+	}
+	slantMeters, elevation := SlantRangeAndElevation(station, point.Latitude, point.Longitude, altitudeMeters)
+	horizonKm := RadioHorizonKm(station.AntennaHeightMeters, altitudeMeters-station.GroundMeters)
+	annotation["lookAngleSlantRangeKm"] = slantMeters / 1000
+	annotation["lookAngleSlantRangeNm"] = slantMeters / 1000 / kmPerNauticalMile
+	annotation["lookAngleElevationDegrees"] = elevation
+	annotation["lookAngleRadioHorizonKm"] = horizonKm
+	annotation["lookAngleLineOfSight"] = km <= horizonKm
+	return annotation
+}
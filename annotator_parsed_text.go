@@ -0,0 +1,33 @@
+package main
+
+import "strings"
+
+type ParsedTextAnnotator struct {
+}
+
+func (a ParsedTextAnnotator) Name() string {
+	return "parsed text"
+}
+
+func (a ParsedTextAnnotator) SelectFields(annotation Annotation) Annotation {
+	if config.ParsedTextAnnotatorSelectedFields == "" {
+		return annotation
+	}
+	selectedFields := Annotation{}
+	for field, value := range annotation {
+		if strings.Contains(config.ParsedTextAnnotatorSelectedFields, field) {
+			selectedFields[field] = value
+		}
+	}
+	return selectedFields
+}
+
+// Interface function to satisfy ACARSHandler
+func (a ParsedTextAnnotator) AnnotateACARSMessage(m ACARSMessage) (annotation Annotation) {
+	return ParseMessageText(m.Label, m.MessageText)
+}
+
+// Interface function to satisfy VDLM2Handler
+func (a ParsedTextAnnotator) AnnotateVDLM2Message(m VDLM2Message) (annotation Annotation) {
+	return ParseMessageText(m.VDL2.AVLC.ACARS.Label, m.VDL2.AVLC.ACARS.MessageText)
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"github.com/jftuga/geodist"
+	log "github.com/sirupsen/logrus"
+)
+
+type ReceptionAnnotator struct {
+}
+
+func (a ReceptionAnnotator) Name() string {
+	return "reception"
+}
+
+func (a ReceptionAnnotator) SelectFields(annotation Annotation) Annotation {
+	if config.ReceptionAnnotatorSelectedFields == "" {
+		return annotation
+	}
+	selectedFields := Annotation{}
+	for field, value := range annotation {
+		if strings.Contains(config.ReceptionAnnotatorSelectedFields, field) {
+			selectedFields[field] = value
+		}
+	}
+	return selectedFields
+}
+
+// Interface function to satisfy ACARSHandler
+func (a ReceptionAnnotator) AnnotateACARSMessage(m ACARSMessage) (annotation Annotation) {
+	// Uplinks are heard from the ground station, so they say nothing about
+	// how far away aircraft can be heard
+	if DecodeACARSLabel(m.Label, m.BlockID, m.MessageText)["acarsDirection"] != ACARSDirectionDownlink {
+		return nil
+	}
+	return a.AnnotateReception(m.StationID, m.FrequencyMHz, m.SignaldBm, ACARSMessageAircraft(m))
+}
+
+// Interface function to satisfy VDLM2Handler
+func (a ReceptionAnnotator) AnnotateVDLM2Message(m VDLM2Message) (annotation Annotation) {
+	if m.VDL2.AVLC.Source.Type != "Aircraft" {
+		return nil
+	}
+	return a.AnnotateReception(m.VDL2.Station, float64(m.VDL2.FrequencyHz)/1e6, m.VDL2.SignalLevel, VDLM2MessageAircraft(m))
+}
+
+// Adds the message to the station's coverage and says how it compares
+func (a ReceptionAnnotator) AnnotateReception(stationID string, frequencyMHz, signalLevel float64, aircraft MessageAircraft) (annotation Annotation) {
+	station, ok := StationLocation()
+	if !ok {
+		log.Warn("reception annotator enabled but STATION_GEOLOCATION is not in the format 'LAT,LON'")
+		return nil
+	}
+	point, _, found := aircraft.Position().Point()
+	if !found {
+		return nil
+	}
+	_, km := geodist.HaversineDistance(
+		geodist.Coord{Lat: station.Latitude, Lon: station.Longitude},
+		geodist.Coord{Lat: point.Latitude, Lon: point.Longitude},
+	)
+	bearing := Bearing(station.Latitude, station.Longitude, point.Latitude, point.Longitude)
+	sector, sectorMaxKm, newRecord := receptionStats.Record(stationID, frequencyMHz, ReceptionRecord{
+		Time:           time.Now(),
+		Registration:   strings.TrimLeft(aircraft.Registration, "."),
+		Latitude:       point.Latitude,
+		Longitude:      point.Longitude,
+		DistanceKm:     km,
+		BearingDegrees: bearing,
+		SignalLevel:    signalLevel,
+	})
+	return Annotation{
+		"receptionDistanceKm":       km,
+		"receptionBearingDegrees":   bearing,
+		"receptionSector":           sector,
+		"receptionSectorMaxRangeKm": sectorMaxKm,
+		"receptionSectorRecord":     newRecord,
+		"receptionSignalBucket":     receptionStats.SignalBucket(signalLevel),
+	}
+}
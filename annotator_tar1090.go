@@ -4,11 +4,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
-	"net/http"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/jftuga/geodist"
 	log "github.com/sirupsen/logrus"
@@ -44,32 +41,48 @@ type Tar1090AircraftJSON struct {
 	Aircraft []TJSONAircraft `json:"aircraft,omitempty"`
 }
 
-// The FIXME values are because I don't know what they are
+// Fields from readsb's aircraft.json, see
+// https://github.com/wiedehopf/readsb/blob/dev/README-json.md
 type TJSONAircraft struct {
-	Hex                        string   `json:"hex,omitempty"`
-	Type                       string   `json:"type,omitempty"`
-	AircraftTailCode           string   `json:"flight,omitempty"`
-	Registration               string   `json:"r,omitempty"`
-	AircraftType               string   `json:"t,omitempty"`
-	AircraftDescription        string   `json:"desc,omitempty"`
-	AircraftOwnerOperator      string   `json:"ownOp,omitempty"`
-	AircraftManufactureYear    string   `json:"year,omitempty"`
-	AltimeterBarometerFeet     int64    `json:"alt_baro,omitempty"`
-	AltimeterBarometerRateFeet float64  `json:"baro_rate,omitempty"`
-	Squawk                     string   `json:"squawk,omitempty"`
-	Emergency                  string   `json:"emergency,omitempty"`
-	NavQNH                     float64  `json:"nav_qnh,omitempty"`
-	NavAltitudeMCP             int64    `json:"nav_altitude_mcp,omitempty"`
-	NavModes                   []string `json:"nav_modes,omitempty"`
+	Hex                        string          `json:"hex,omitempty"`
+	Type                       string          `json:"type,omitempty"`
+	AircraftTailCode           string          `json:"flight,omitempty"`
+	Registration               string          `json:"r,omitempty"`
+	AircraftType               string          `json:"t,omitempty"`
+	AircraftDescription        string          `json:"desc,omitempty"`
+	AircraftOwnerOperator      string          `json:"ownOp,omitempty"`
+	AircraftManufactureYear    string          `json:"year,omitempty"`
+	DatabaseFlags              int64           `json:"dbFlags,omitempty"`
+	AltimeterBarometer         Tar1090Altitude `json:"alt_baro,omitempty"`
+	AltimeterBarometerRateFeet float64         `json:"baro_rate,omitempty"`
+	GeometricRateFeet          float64         `json:"geom_rate,omitempty"`
+	Squawk                     string          `json:"squawk,omitempty"`
+	Emergency                  string          `json:"emergency,omitempty"`
+	NavQNH                     float64         `json:"nav_qnh,omitempty"`
+	NavAltitudeMCP             int64           `json:"nav_altitude_mcp,omitempty"`
+	NavAltitudeFMS             int64           `json:"nav_altitude_fms,omitempty"`
+	NavHeading                 float64         `json:"nav_heading,omitempty"`
+	NavModes                   []string        `json:"nav_modes,omitempty"`
 
 	AltimeterGeometricFeet       float64 `json:"alt_geom,omitempty"`
-	GsFIXME                      float64 `json:"gs,omitempty"`
+	GroundSpeedKnots             float64 `json:"gs,omitempty"`
+	IndicatedAirSpeedKnots       float64 `json:"ias,omitempty"`
+	TrueAirSpeedKnots            float64 `json:"tas,omitempty"`
+	Mach                         float64 `json:"mach,omitempty"`
 	Track                        float64 `json:"track,omitempty"`
+	TrackRate                    float64 `json:"track_rate,omitempty"`
+	Roll                         float64 `json:"roll,omitempty"`
+	MagneticHeading              float64 `json:"mag_heading,omitempty"`
+	TrueHeading                  float64 `json:"true_heading,omitempty"`
+	WindDirection                float64 `json:"wd,omitempty"`
+	WindSpeedKnots               float64 `json:"ws,omitempty"`
+	OutsideAirTemperatureC       float64 `json:"oat,omitempty"`
+	TotalAirTemperatureC         float64 `json:"tat,omitempty"`
 	Category                     string  `json:"category,omitempty"`
 	Latitude                     float64 `json:"lat,omitempty"`
 	Longitude                    float64 `json:"lon,omitempty"`
-	NICFIXME                     int64   `json:"nic,omitempty"`
-	RCFIXME                      int64   `json:"rc,omitempty"`
+	NavigationIntegrityCategory  int64   `json:"nic,omitempty"`
+	RadiusOfContainmentMeters    int64   `json:"rc,omitempty"`
 	SeenPosition                 float64 `json:"seen_pos,omitempty"`
 	DistanceFromReceiverNm       float64 `json:"r_dst,omitempty"`
 	DirectionFromReceiverDegrees float64 `json:"r_dir,omitempty"`
@@ -83,57 +96,177 @@ type TJSONAircraft struct {
 	SPI                          int64   `json:"spi,omitempty"`
 	GVA                          int64   `json:"gva,omitempty"`
 	SDA                          int64   `json:"sda,omitempty"`
-	// TODO
-	// MLAT                         []struct {
-	// } `json:"mlat,omitempty"`
-	// TISB []struct {
-	// } `json:"tisb,omitempty"`
-	MessageCount       int64   `json:"messages,omitempty"`
-	Seen               float64 `json:"seen,omitempty"`
-	RSSISignalPowerdBm float64 `json:"rssi,omitempty"`
+	// Names of the fields that came from MLAT or TIS-B rather than ADS-B
+	MLAT               []string `json:"mlat,omitempty"`
+	TISB               []string `json:"tisb,omitempty"`
+	MessageCount       int64    `json:"messages,omitempty"`
+	Seen               float64  `json:"seen,omitempty"`
+	RSSISignalPowerdBm float64  `json:"rssi,omitempty"`
 }
 
-type MLAT struct {
+// aircraft.json dbFlags is a bitfield, unlike the string of 0s and 1s in
+// tar1090-db's aircraft.csv. The lowest bit is military.
+const tar1090DBFlagMilitary = 1
+
+// alt_baro is a number of feet, or "ground"
+type Tar1090Altitude struct {
+	Feet     int64
+	OnGround bool
+	// Whether alt_baro was there at all, since 0 ft is a real altitude
+	Known bool
 }
 
-type TISB struct {
+func (t *Tar1090Altitude) UnmarshalJSON(data []byte) error {
+	var ground string
+	if json.Unmarshal(data, &ground) == nil {
+		t.OnGround = ground == "ground"
+		t.Known = t.OnGround
+		return nil
+	}
+	var feet float64
+	if err := json.Unmarshal(data, &feet); err != nil {
+		return err
+	}
+	t.Feet, t.Known = int64(feet), true
+	return nil
 }
 
-// Wrapper around the SingleAircraftQueryByRegistration API
-func (a Tar1090Handler) SingleAircraftQueryByRegistration(reg string) (aircraft TJSONAircraft, err error) {
-	reg = NormalizeAircraftRegistration(reg)
-	req, err := http.NewRequest("GET", fmt.Sprintf("%s/data/aircraft.json?_=%d/", config.TAR1090URL, time.Now().Unix()), nil)
-	if err != nil {
-		return aircraft, err
+func (t Tar1090Altitude) MarshalJSON() ([]byte, error) {
+	if t.OnGround {
+		return json.Marshal("ground")
 	}
-	client := &http.Client{}
+	return json.Marshal(t.Feet)
+}
 
-	log.Debug("making call to tar1090")
-	resp, err := client.Do(req)
-	if err != nil {
-		log.Error(err)
+// Where the position came from, for the tar1090PositionSource field
+const (
+	Tar1090PositionSourceADSB  = "ADS-B"
+	Tar1090PositionSourceMLAT  = "MLAT"
+	Tar1090PositionSourceTISB  = "TIS-B"
+	Tar1090PositionSourceADSR  = "ADS-R"
+	Tar1090PositionSourceADSC  = "ADS-C"
+	Tar1090PositionSourceOther = "other"
+)
+
+// Works out where the position came from using the message type and the
+// lists of fields derived from MLAT and TIS-B
+func (aircraft TJSONAircraft) PositionSource() string {
+	for _, field := range aircraft.MLAT {
+		if field == "lat" || field == "lon" {
+			return Tar1090PositionSourceMLAT
+		}
 	}
-	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
-	tjson := Tar1090AircraftJSON{}
-	err = json.Unmarshal(body, &tjson)
-	if err != nil {
-		return aircraft, err
+	for _, field := range aircraft.TISB {
+		if field == "lat" || field == "lon" {
+			return Tar1090PositionSourceTISB
+		}
 	}
+	switch {
+	case strings.HasPrefix(aircraft.Type, "adsb"):
+		return Tar1090PositionSourceADSB
+	case strings.HasPrefix(aircraft.Type, "mlat"):
+		return Tar1090PositionSourceMLAT
+	case strings.HasPrefix(aircraft.Type, "tisb"):
+		return Tar1090PositionSourceTISB
+	case strings.HasPrefix(aircraft.Type, "adsr"):
+		return Tar1090PositionSourceADSR
+	case aircraft.Type == "adsc":
+		return Tar1090PositionSourceADSC
+	case aircraft.Type == "":
+		// Older versions don't send a type
+		return Tar1090PositionSourceADSB
+	}
+	return Tar1090PositionSourceOther
+}
 
-	if (&tjson != &Tar1090AircraftJSON{}) {
-		for _, aircraft := range tjson.Aircraft {
-			// Strip spaces and periods
-			if NormalizeAircraftRegistration(aircraft.Registration) == reg {
-				log.Debug("returning data from tar1090")
-				return aircraft, nil
-			}
-		}
+// Fields both the ACARS and VDLM2 annotations include beyond the basics
+func (aircraft TJSONAircraft) ExtraFields() Annotation {
+	return Annotation{
+		"tar1090AircraftOnGround":                    aircraft.AltimeterBarometer.OnGround,
+		"tar1090AircraftGeometricRateFeetPerMinute":  aircraft.GeometricRateFeet,
+		"tar1090AircraftGroundSpeedKnots":            aircraft.GroundSpeedKnots,
+		"tar1090AircraftIndicatedAirSpeedKnots":      aircraft.IndicatedAirSpeedKnots,
+		"tar1090AircraftTrueAirSpeedKnots":           aircraft.TrueAirSpeedKnots,
+		"tar1090AircraftMach":                        aircraft.Mach,
+		"tar1090AircraftTrackDegrees":                aircraft.Track,
+		"tar1090AircraftTrackRateDegreesPerSecond":   aircraft.TrackRate,
+		"tar1090AircraftRollDegrees":                 aircraft.Roll,
+		"tar1090AircraftMagneticHeadingDegrees":      aircraft.MagneticHeading,
+		"tar1090AircraftTrueHeadingDegrees":          aircraft.TrueHeading,
+		"tar1090AircraftWindDirectionDegrees":        aircraft.WindDirection,
+		"tar1090AircraftWindSpeedKnots":              aircraft.WindSpeedKnots,
+		"tar1090AircraftOutsideAirTemperatureC":      aircraft.OutsideAirTemperatureC,
+		"tar1090AircraftTotalAirTemperatureC":        aircraft.TotalAirTemperatureC,
+		"tar1090AircraftSquawk":                      aircraft.Squawk,
+		"tar1090AircraftCategory":                    aircraft.Category,
+		"tar1090AircraftRegistration":                aircraft.Registration,
+		"tar1090AircraftMilitary":                    aircraft.DatabaseFlags&tar1090DBFlagMilitary != 0,
+		"tar1090AircraftNavQNH":                      aircraft.NavQNH,
+		"tar1090AircraftNavAltitudeMCPFeet":          aircraft.NavAltitudeMCP,
+		"tar1090AircraftNavAltitudeFMSFeet":          aircraft.NavAltitudeFMS,
+		"tar1090AircraftNavHeadingDegrees":           aircraft.NavHeading,
+		"tar1090AircraftNavigationIntegrityCategory": aircraft.NavigationIntegrityCategory,
+		"tar1090AircraftRadiusOfContainmentMeters":   aircraft.RadiusOfContainmentMeters,
+		"tar1090AircraftNavigationAccuracyPosition":  aircraft.NACP,
+		"tar1090AircraftNavigationAccuracyVelocity":  aircraft.NACV,
+		"tar1090AircraftSourceIntegrityLevel":        aircraft.SIL,
+		"tar1090AircraftADSBVersion":                 aircraft.Version,
+		"tar1090AircraftSecondsSinceSeen":            aircraft.Seen,
+		"tar1090AircraftSecondsSincePositionSeen":    aircraft.SeenPosition,
+		"tar1090AircraftMessageType":                 aircraft.Type,
+		"tar1090PositionSource":                      aircraft.PositionSource(),
+	}
+}
+
+// What a tar1090 lookup matched on, for the tar1090MatchedBy field
+const (
+	Tar1090MatchedByHex          = "hex"
+	Tar1090MatchedByRegistration = "registration"
+	Tar1090MatchedByCallsign     = "callsign"
+)
+
+// Finds an aircraft by registration in the cached aircraft.json or stream
+func (a Tar1090Handler) SingleAircraftQueryByRegistration(reg string) (aircraft TJSONAircraft, err error) {
+	aircraft, found := aircraftSource.LookupRegistration(reg)
+	if !found {
 		log.Debug("aircraft not found in tar1090 response")
 		return aircraft, errors.New("aircraft not found in tar1090 response")
-	} else {
-		return aircraft, errors.New("unable to parse returned aircraft position")
 	}
+	log.Debug("returning data from tar1090")
+	return aircraft, nil
+}
+
+// Finds an aircraft by ICAO hex, then registration, then callsign. Many
+// feeders don't have a database so registration is often missing, and ACARS
+// flight IDs use IATA codes ("UA0123") where ADS-B callsigns use ICAO
+// ("UAL123"), so the flight ID is tried in both forms.
+func (a Tar1090Handler) FindAircraft(hex, reg, flight string) (aircraft TJSONAircraft, matchedBy string, err error) {
+	if hex != "" {
+		if aircraft, found := aircraftSource.LookupHex(hex); found {
+			return aircraft, Tar1090MatchedByHex, nil
+		}
+	}
+	if reg != "" {
+		if aircraft, err := a.SingleAircraftQueryByRegistration(reg); err == nil {
+			return aircraft, Tar1090MatchedByRegistration, nil
+		}
+	}
+	if flight != "" {
+		callsigns := []string{flight}
+		// Callsigns with a letter suffix have no IATA form
+		decoded := DecodeFlightNumber(flight)
+		for _, form := range []string{"flightNumberICAO", "flightNumberIATA"} {
+			if callsign, ok := decoded[form].(string); ok {
+				callsigns = append(callsigns, callsign)
+			}
+		}
+		for _, callsign := range callsigns {
+			if aircraft, found := aircraftSource.LookupCallsign(callsign); found {
+				return aircraft, Tar1090MatchedByCallsign, nil
+			}
+		}
+	}
+	return aircraft, "", errors.New("aircraft not found in tar1090 response")
 }
 
 // Interface function to satisfy ACARSHandler
@@ -151,10 +284,12 @@ func (a Tar1090Handler) AnnotateACARSMessage(m ACARSMessage) (annotation Annotat
 	olon, _ := strconv.ParseFloat(coords[1], 64)
 	origin := geodist.Coord{Lat: olat, Lon: olon}
 
-	aircraftInfo, err := a.SingleAircraftQueryByRegistration(m.AircraftTailCode)
+	// ACARS doesn't carry the ICAO hex but the aircraft database might know it
+	record, _ := aircraftDB.LookupRegistration(m.AircraftTailCode)
+	aircraftInfo, matchedBy, err := a.FindAircraft(record.ICAOHex, m.AircraftTailCode, m.FlightNumber)
 	if err != nil {
 		log.Warnf("error getting aircraft position from tar1090: %v", err)
-		return annotation
+		return a.AnnotateTextPosition(origin, config.TAR1090ReferenceGeolocation, m.MessageText)
 	}
 
 	aircraft := geodist.Coord{Lat: aircraftInfo.Latitude, Lon: aircraftInfo.Longitude}
@@ -163,13 +298,7 @@ func (a Tar1090Handler) AnnotateACARSMessage(m ACARSMessage) (annotation Annotat
 		log.Warnf("error calculating distance: %s", err)
 	}
 
-	var navmodes string
-	for i, mode := range aircraftInfo.NavModes {
-		if i != 0 {
-			navmodes = mode + ","
-		}
-		navmodes = navmodes + mode
-	}
+	navmodes := strings.Join(aircraftInfo.NavModes, ",")
 	event := Annotation{
 		"tar1090OriginGeolocation":                           config.TAR1090ReferenceGeolocation,
 		"tar1090OriginGeolocationLatitude":                   olat,
@@ -180,9 +309,9 @@ func (a Tar1090Handler) AnnotateACARSMessage(m ACARSMessage) (annotation Annotat
 		"tar1090AircraftLongitude":                           aircraftInfo.Longitude,
 		"tar1090AircraftDistanceKm":                          km,
 		"tar1090AircraftDistanceMi":                          mi,
-		"tar1090AircraftDistanceNm":                          aircraftInfo.DistanceFromReceiverNm,
+		"tar1090AircraftDistanceNm":                          km / kmPerNauticalMile,
 		"tar1090AircraftDirectionDegrees":                    aircraftInfo.DirectionFromReceiverDegrees,
-		"tar1090AircraftAltimeterBarometerFeet":              aircraftInfo.AltimeterBarometerFeet,
+		"tar1090AircraftAltimeterBarometerFeet":              aircraftInfo.AltimeterBarometer.Feet,
 		"tar1090AircraftAltimeterGeometricFeet":              aircraftInfo.AltimeterGeometricFeet,
 		"tar1090AircraftAltimeterBarometerRateFeetPerSecond": aircraftInfo.AltimeterBarometerRateFeet,
 		"tar1090AircraftOwnerOperator":                       aircraftInfo.AircraftOwnerOperator,
@@ -194,30 +323,39 @@ func (a Tar1090Handler) AnnotateACARSMessage(m ACARSMessage) (annotation Annotat
 		"tar1090AircraftADSBMessageCount":                    aircraftInfo.MessageCount,
 		"tar1090AircraftRSSIdBm":                             aircraftInfo.RSSISignalPowerdBm,
 		"tar1090AircraftNavModes":                            navmodes,
+		"tar1090MatchedBy":                                   matchedBy,
+		"positionSource":                                     PositionSourceADSB,
 	}
 
-	return event
+	if age, ok := Tar1090DataAge(aircraftInfo); ok {
+		event["tar1090DataAgeSeconds"] = age
+	}
+	return MergeMaps(event, aircraftInfo.ExtraFields())
 }
 
 // Interface function to satisfy ACARSHandler
 func (a Tar1090Handler) AnnotateVDLM2Message(m VDLM2Message) (annotation Annotation) {
-	if config.ADSBExchangeReferenceGeolocation == "" {
+	if config.TAR1090ReferenceGeolocation == "" {
 		log.Info("tar1090 enabled but geolocation not set, using '0,0'")
-		config.ADSBExchangeReferenceGeolocation = "0,0"
+		config.TAR1090ReferenceGeolocation = "0,0"
 	}
-	coords := strings.Split(config.ADSBExchangeReferenceGeolocation, ",")
+	coords := strings.Split(config.TAR1090ReferenceGeolocation, ",")
 	if len(coords) != 2 {
-		log.Warn("geolocation coordinates are not in the format 'LAT,LON'")
+		log.Warn("tar1090 geolocation coordinates are not in the format 'LAT,LON'")
 		return annotation
 	}
 	olat, _ := strconv.ParseFloat(coords[0], 64)
 	olon, _ := strconv.ParseFloat(coords[1], 64)
 	origin := geodist.Coord{Lat: olat, Lon: olon}
 
-	aircraftInfo, err := a.SingleAircraftQueryByRegistration(NormalizeAircraftRegistration(m.VDL2.AVLC.ACARS.Registration))
+	var hex string
+	if m.VDL2.AVLC.Source.Type == "Aircraft" {
+		hex = m.VDL2.AVLC.Source.Address
+	}
+	aircraftInfo, matchedBy, err := a.FindAircraft(hex, m.VDL2.AVLC.ACARS.Registration, m.VDL2.AVLC.ACARS.FlightNumber)
 	if err != nil {
 		log.Warnf("error getting aircraft position: %v", err)
-		return annotation
+		return a.AnnotateTextPosition(origin, config.TAR1090ReferenceGeolocation, m.VDL2.AVLC.ACARS.MessageText)
 	}
 
 	alat, alon := aircraftInfo.Latitude, aircraftInfo.Longitude
@@ -227,13 +365,7 @@ func (a Tar1090Handler) AnnotateVDLM2Message(m VDLM2Message) (annotation Annotat
 		log.Warnf("error calculating distance: %s", err)
 	}
 
-	var navmodes string
-	for i, mode := range aircraftInfo.NavModes {
-		if i != 0 {
-			navmodes = mode + ","
-		}
-		navmodes = navmodes + mode
-	}
+	navmodes := strings.Join(aircraftInfo.NavModes, ",")
 	event := Annotation{
 		"tar1090OriginGeolocation":                           config.TAR1090ReferenceGeolocation,
 		"tar1090OriginGeolocationLatitude":                   olat,
@@ -243,9 +375,9 @@ func (a Tar1090Handler) AnnotateVDLM2Message(m VDLM2Message) (annotation Annotat
 		"tar1090AircraftLongitude":                           alon,
 		"tar1090AircraftDistanceKm":                          km,
 		"tar1090AircraftDistanceMi":                          mi,
-		"tar1090AircraftDistanceNm":                          aircraftInfo.DistanceFromReceiverNm,
+		"tar1090AircraftDistanceNm":                          km / kmPerNauticalMile,
 		"tar1090AircraftDirectionDegrees":                    aircraftInfo.DirectionFromReceiverDegrees,
-		"tar1090AircraftAltimeterBarometerFeet":              aircraftInfo.AltimeterBarometerFeet,
+		"tar1090AircraftAltimeterBarometerFeet":              aircraftInfo.AltimeterBarometer.Feet,
 		"tar1090AircraftAltimeterGeometricFeet":              aircraftInfo.AltimeterGeometricFeet,
 		"tar1090AircraftAltimeterBarometerRateFeetPerSecond": aircraftInfo.AltimeterBarometerRateFeet,
 		"tar1090AircraftOwnerOperator":                       aircraftInfo.AircraftOwnerOperator,
@@ -257,7 +389,48 @@ func (a Tar1090Handler) AnnotateVDLM2Message(m VDLM2Message) (annotation Annotat
 		"tar1090AircraftADSBMessageCount":                    aircraftInfo.MessageCount,
 		"tar1090AircraftRSSIdBm":                             aircraftInfo.RSSISignalPowerdBm,
 		"tar1090AircraftNavModes":                            navmodes,
+		"tar1090MatchedBy":                                   matchedBy,
+		"positionSource":                                     PositionSourceADSB,
+	}
+
+	if age, ok := Tar1090DataAge(aircraftInfo); ok {
+		event["tar1090DataAgeSeconds"] = age
+	}
+	return MergeMaps(event, aircraftInfo.ExtraFields())
+}
+
+// When tar1090 can't see the aircraft, use a position from the message text
+// if there is one so distance fields are still available
+func (a Tar1090Handler) AnnotateTextPosition(origin geodist.Coord, originGeolocation, text string) (annotation Annotation) {
+	alat, alon, found := ExtractPositionFromText(text)
+	if !found {
+		return annotation
+	}
+	log.Debug("using position from message text")
+	mi, km, err := geodist.VincentyDistance(origin, geodist.Coord{Lat: alat, Lon: alon})
+	if err != nil {
+		log.Warnf("error calculating distance: %s", err)
 	}
+	return Annotation{
+		"tar1090OriginGeolocation":          originGeolocation,
+		"tar1090OriginGeolocationLatitude":  origin.Lat,
+		"tar1090OriginGeolocationLongitude": origin.Lon,
+		"tar1090AircraftGeolocation":        fmt.Sprintf("%f,%f", alat, alon),
+		"tar1090AircraftLatitude":           alat,
+		"tar1090AircraftLongitude":          alon,
+		"tar1090AircraftDistanceKm":         km,
+		"tar1090AircraftDistanceMi":         mi,
+		"tar1090AircraftDistanceNm":         km / kmPerNauticalMile,
+		"positionSource":                    PositionSourceMessage,
+	}
+}
 
-	return event
+// How old the aircraft's position is, or how far behind the source is if
+// there's no position. Sources bring seen_pos up to the present on lookup.
+func Tar1090DataAge(aircraft TJSONAircraft) (seconds float64, ok bool) {
+	if aircraft.Latitude != 0 || aircraft.Longitude != 0 {
+		return aircraft.SeenPosition, true
+	}
+	age, ok := aircraftSource.DataAge()
+	return age.Seconds(), ok
 }
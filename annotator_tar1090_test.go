@@ -0,0 +1,100 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func useAircraftSource(t *testing.T, source AircraftSource) {
+	previous, previousConfig := aircraftSource, config
+	t.Cleanup(func() { aircraftSource, config = previous, previousConfig })
+	aircraftSource = source
+}
+
+func TestTar1090FallsBackToTextPosition(t *testing.T) {
+	useAircraftSource(t, NewAircraftStateTable(time.Minute))
+	config.TAR1090ReferenceGeolocation = "40,-74"
+
+	tests := []struct {
+		name       string
+		text       string
+		wantSource any
+		wantKm     float64
+	}{
+		// 0.5 degrees of latitude north of the receiver
+		{"position in text", "POS N4030.0W07400.0,FL350", PositionSourceMessage, 55.5},
+		{"no position", "REQUEST MEDICAL ASSISTANCE", nil, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			annotation := Tar1090Handler{}.AnnotateACARSMessage(ACARSMessage{AircraftTailCode: "N12345", MessageText: test.text})
+			if annotation["positionSource"] != test.wantSource {
+				t.Fatalf("got %v", annotation)
+			}
+			if test.wantSource == nil {
+				return
+			}
+			km, _ := annotation["tar1090AircraftDistanceKm"].(float64)
+			if math.Abs(km-test.wantKm) > 0.5 || annotation["tar1090AircraftLatitude"] != 40.5 {
+				t.Errorf("got %v", annotation)
+			}
+		})
+	}
+}
+
+func TestTar1090FindAircraft(t *testing.T) {
+	table := NewAircraftStateTable(time.Minute)
+	table.Update("a1b2c3", func(aircraft *TJSONAircraft) bool {
+		aircraft.Registration, aircraft.AircraftTailCode = "N12345", "UAL123  "
+		return true
+	})
+	// No registration, like a feeder without a database
+	table.Update("d4e5f6", func(aircraft *TJSONAircraft) bool {
+		aircraft.AircraftTailCode = "BAW456"
+		return true
+	})
+	useAircraftSource(t, table)
+
+	tests := []struct {
+		name, hex, reg, flight string
+		wantHex, wantMatchedBy string
+	}{
+		{"hex first", "A1B2C3", "N99999", "", "a1b2c3", Tar1090MatchedByHex},
+		{"registration", "", ".N12345", "", "a1b2c3", Tar1090MatchedByRegistration},
+		{"unknown hex falls through", "FFFFFF", "N12345", "", "a1b2c3", Tar1090MatchedByRegistration},
+		{"iata flight", "", "G-EUPA", "BA0456", "d4e5f6", Tar1090MatchedByCallsign},
+		{"icao flight", "", "", "UAL123", "a1b2c3", Tar1090MatchedByCallsign},
+		// No IATA form, which used to panic
+		{"alphanumeric callsign", "", "", "BAW45K", "", ""},
+		{"nothing", "", "N99999", "DL1", "", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			aircraft, matchedBy, err := Tar1090Handler{}.FindAircraft(test.hex, test.reg, test.flight)
+			if aircraft.Hex != test.wantHex || matchedBy != test.wantMatchedBy || (err != nil) != (test.wantHex == "") {
+				t.Errorf("got %q by %q, error %v", aircraft.Hex, matchedBy, err)
+			}
+		})
+	}
+}
+
+func TestTar1090VDLM2UsesTar1090Origin(t *testing.T) {
+	table := NewAircraftStateTable(time.Minute)
+	table.Update("a1b2c3", func(aircraft *TJSONAircraft) bool {
+		aircraft.Latitude, aircraft.Longitude = 40.5, -74
+		return true
+	})
+	useAircraftSource(t, table)
+	config.TAR1090ReferenceGeolocation = "40,-74"
+	config.ADSBExchangeReferenceGeolocation = "0,0"
+
+	var m VDLM2Message
+	m.VDL2.AVLC.Source.Type = "Aircraft"
+	m.VDL2.AVLC.Source.Address = "A1B2C3"
+	annotation := Tar1090Handler{}.AnnotateVDLM2Message(m)
+	km, _ := annotation["tar1090AircraftDistanceKm"].(float64)
+	if annotation["tar1090MatchedBy"] != Tar1090MatchedByHex || math.Abs(km-55.5) > 0.5 {
+		t.Errorf("got %v", annotation)
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"strings"
+	"time"
+)
+
+type TrackAnnotator struct {
+}
+
+func (a TrackAnnotator) Name() string {
+	return "track"
+}
+
+func (a TrackAnnotator) SelectFields(annotation Annotation) Annotation {
+	if config.TrackAnnotatorSelectedFields == "" {
+		return annotation
+	}
+	selectedFields := Annotation{}
+	for field, value := range annotation {
+		if strings.Contains(config.TrackAnnotatorSelectedFields, field) {
+			selectedFields[field] = value
+		}
+	}
+	return selectedFields
+}
+
+// Interface function to satisfy ACARSHandler
+func (a TrackAnnotator) AnnotateACARSMessage(m ACARSMessage) (annotation Annotation) {
+	return a.AnnotateTrack(ACARSMessageAircraft(m))
+}
+
+// Interface function to satisfy VDLM2Handler
+func (a TrackAnnotator) AnnotateVDLM2Message(m VDLM2Message) (annotation Annotation) {
+	return a.AnnotateTrack(VDLM2MessageAircraft(m))
+}
+
+// Adds what the message tells us to the track, then describes the track
+func (a TrackAnnotator) AnnotateTrack(aircraft MessageAircraft) (annotation Annotation) {
+	position := aircraft.Position()
+	hex := aircraft.Hex
+	// The position source knows the hex even when the message doesn't
+	if position.HasLive {
+		hex = position.Live.Hex
+		trackHistory.RecordAircraft(position.Live)
+	}
+	key := TrackKey(hex, aircraft.Registration)
+	if key == "" {
+		return nil
+	}
+	if position.HasMessage {
+		trackHistory.Record(key, position.Message)
+	}
+
+	points, takeoff := trackHistory.Track(key)
+	if len(points) == 0 {
+		return nil
+	}
+	annotation = Annotation{
+		"trackPointCount":  len(points),
+		"trackPolyline":    EncodePolyline(points),
+		"trackCoordinates": TrackCoordinates(points),
+	}
+	if phase := FlightPhase(points, takeoff); phase != "" {
+		annotation["trackFlightPhase"] = phase
+	}
+	if rate, ok := TrackVerticalRate(points); ok {
+		annotation["trackVerticalRateFeetPerMinute"] = rate
+		annotation["trackVerticalTrend"] = VerticalTrend(rate)
+	}
+	if !takeoff.IsZero() {
+		annotation["trackSecondsSinceTakeoff"] = time.Since(takeoff).Seconds()
+	}
+	return annotation
+}
+
+// A position reported in the message text or an ADS-C report, with altitude
+// if it was given
+func MessageTrackPoint(label, text string) (point TrackPoint, found bool) {
+	point.Time = time.Now()
+	parsed := ParseMessageText(label, text)
+	lat, latOK := parsed["parsedLatitude"].(float64)
+	lon, lonOK := parsed["parsedLongitude"].(float64)
+	if latOK && lonOK {
+		point.Latitude, point.Longitude = lat, lon
+		point.AltitudeFeet, point.HasAltitude = parsed["parsedAltitude"].(int64)
+		return point, true
+	}
+	if adsc := DecodeARINC622(text, ACARSDirectionDownlink); adsc != nil {
+		lat, latOK := adsc["adscLatitude"].(float64)
+		lon, lonOK := adsc["adscLongitude"].(float64)
+		if latOK && lonOK {
+			point.Latitude, point.Longitude = lat, lon
+			point.AltitudeFeet, point.HasAltitude = adsc["adscAltitudeFeet"].(int64)
+			return point, true
+		}
+	}
+	point.Latitude, point.Longitude, found = ExtractPositionFromText(text)
+	return point, found
+}
@@ -8,17 +8,76 @@ func ConfigureAnnotators() {
 		log.Info("ACARS annotator enabled")
 		enabledACARSAnnotators = append(enabledACARSAnnotators, ACARSHandlerAnnotator{})
 	}
-	if config.ADSBExchangeAPIKey != "" {
+	if ADSBAPIEnabled() {
 		log.Info("ADSB annotator enabled")
-		if config.ADSBExchangeAPIKey == "" {
+		provider := ADSBAPI()
+		if provider.KeyHeader != "" && ADSBAPIKey() == "" {
 			log.Error("ADSB API key not set")
 		}
-		enabledACARSAnnotators = append(enabledACARSAnnotators, ADSBHandlerAnnotator{})
+		ValidateADSBAPIQuery()
+		enabledACARSAnnotators = append(enabledACARSAnnotators, ADSBHandlerAnnotator{Provider: provider})
 	}
-	if config.TAR1090URL != "" {
+	if AircraftPositionsEnabled() {
 		log.Info("TAR1090 annotator enabled")
 		enabledACARSAnnotators = append(enabledACARSAnnotators, Tar1090Handler{})
 	}
+	if config.AnnotateACARSLabels {
+		log.Info("ACARS label annotator enabled")
+		enabledACARSAnnotators = append(enabledACARSAnnotators, ACARSLabelAnnotator{})
+	}
+	if config.AnnotateParsedText {
+		log.Info("parsed text annotator enabled")
+		enabledACARSAnnotators = append(enabledACARSAnnotators, ParsedTextAnnotator{})
+	}
+	if config.AnnotateARINC622 {
+		log.Info("ARINC 622 annotator enabled")
+		enabledACARSAnnotators = append(enabledACARSAnnotators, ARINC622Annotator{})
+	}
+	if config.AircraftDBFile != "" {
+		log.Info("aircraft database annotator enabled")
+		enabledACARSAnnotators = append(enabledACARSAnnotators, AircraftDBAnnotator{})
+	}
+	if config.AnnotateAirlines {
+		log.Info("airline annotator enabled")
+		enabledACARSAnnotators = append(enabledACARSAnnotators, AirlineAnnotator{})
+	}
+	if config.AirportsFile != "" {
+		log.Info("airport annotator enabled")
+		enabledACARSAnnotators = append(enabledACARSAnnotators, AirportAnnotator{})
+	}
+	if config.AnnotateICAOAddress {
+		log.Info("ICAO address annotator enabled")
+		if config.AircraftDBFile == "" {
+			log.Warn("ACARS messages don't include the ICAO address, set AIRCRAFT_DB_FILE to look it up")
+		}
+		enabledACARSAnnotators = append(enabledACARSAnnotators, ICAOAddressAnnotator{})
+	}
+	if config.AnnotateTrack {
+		log.Info("track annotator enabled")
+		enabledACARSAnnotators = append(enabledACARSAnnotators, TrackAnnotator{})
+	}
+	if config.AnnotateLookAngle {
+		log.Info("look angle annotator enabled")
+		if _, ok := StationLocation(); !ok {
+			log.Error("look angle annotator needs STATION_GEOLOCATION")
+		}
+		enabledACARSAnnotators = append(enabledACARSAnnotators, LookAngleAnnotator{})
+	}
+	if config.AnnotateReception {
+		log.Info("reception annotator enabled")
+		if _, ok := StationLocation(); !ok {
+			log.Error("reception annotator needs STATION_GEOLOCATION")
+		}
+		enabledACARSAnnotators = append(enabledACARSAnnotators, ReceptionAnnotator{})
+	}
+	if config.AnnotateLocation {
+		log.Info("location annotator enabled")
+		enabledACARSAnnotators = append(enabledACARSAnnotators, LocationAnnotator{})
+	}
+	if config.AnnotateLLMClassification {
+		log.Infof("LLM classification annotator enabled, using %s", LLMClassificationProvider())
+		enabledACARSAnnotators = append(enabledACARSAnnotators, LLMClassificationAnnotator{})
+	}
 	if len(enabledACARSAnnotators) == 0 {
 		log.Warn("no acars annotators are enabled")
 	}
@@ -28,6 +87,45 @@ func ConfigureAnnotators() {
 		log.Info("VDLM2 annotator enabled")
 		enabledVDLM2Annotators = append(enabledVDLM2Annotators, VDLM2HandlerAnnotator{})
 	}
+	if AircraftPositionsEnabled() {
+		enabledVDLM2Annotators = append(enabledVDLM2Annotators, Tar1090Handler{})
+	}
+	if config.AnnotateACARSLabels {
+		enabledVDLM2Annotators = append(enabledVDLM2Annotators, ACARSLabelAnnotator{})
+	}
+	if config.AnnotateParsedText {
+		enabledVDLM2Annotators = append(enabledVDLM2Annotators, ParsedTextAnnotator{})
+	}
+	if config.AnnotateARINC622 {
+		enabledVDLM2Annotators = append(enabledVDLM2Annotators, ARINC622Annotator{})
+	}
+	if config.AircraftDBFile != "" {
+		enabledVDLM2Annotators = append(enabledVDLM2Annotators, AircraftDBAnnotator{})
+	}
+	if config.AnnotateAirlines {
+		enabledVDLM2Annotators = append(enabledVDLM2Annotators, AirlineAnnotator{})
+	}
+	if config.AirportsFile != "" {
+		enabledVDLM2Annotators = append(enabledVDLM2Annotators, AirportAnnotator{})
+	}
+	if config.AnnotateICAOAddress {
+		enabledVDLM2Annotators = append(enabledVDLM2Annotators, ICAOAddressAnnotator{})
+	}
+	if config.AnnotateTrack {
+		enabledVDLM2Annotators = append(enabledVDLM2Annotators, TrackAnnotator{})
+	}
+	if config.AnnotateLookAngle {
+		enabledVDLM2Annotators = append(enabledVDLM2Annotators, LookAngleAnnotator{})
+	}
+	if config.AnnotateReception {
+		enabledVDLM2Annotators = append(enabledVDLM2Annotators, ReceptionAnnotator{})
+	}
+	if config.AnnotateLocation {
+		enabledVDLM2Annotators = append(enabledVDLM2Annotators, LocationAnnotator{})
+	}
+	if config.AnnotateLLMClassification {
+		enabledVDLM2Annotators = append(enabledVDLM2Annotators, LLMClassificationAnnotator{})
+	}
 	if len(enabledVDLM2Annotators) == 0 {
 		log.Info("no vdlm2 annotators are enabled")
 	}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// ADS-C downlink groups start with a one byte tag
+var ADSCReportTypes = map[uint64]string{
+	3:  "acknowledgement",
+	4:  "negative acknowledgement",
+	5:  "noncompliance notification",
+	6:  "cancel emergency",
+	7:  "periodic report",
+	9:  "emergency periodic report",
+	10: "lateral deviation change event",
+	18: "vertical rate change event",
+	19: "altitude range event",
+	20: "waypoint change event",
+}
+
+// How many bits follow the tag for each group we decode
+var adscGroupBits = map[uint64]int{
+	3:  8,
+	6:  8,
+	7:  78,
+	9:  78,
+	10: 78,
+	18: 78,
+	19: 78,
+	20: 78,
+	12: 48,
+	13: 130,
+	14: 38,
+	15: 38,
+	16: 31,
+	17: 24,
+}
+
+// Coordinates are 21 bit two's complement with a range of +/-180 degrees
+const adscCoordinateBits = 21
+
+func readADSCCoordinate(r *bitReader) (float64, error) {
+	value, err := r.readSigned(adscCoordinateBits)
+	return float64(value) * 180 / math.Exp2(adscCoordinateBits-1), err
+}
+
+// Altitudes are 16 bit two's complement in 4 foot increments
+func readADSCAltitude(r *bitReader) (int64, error) {
+	value, err := r.readSigned(16)
+	return value * 4, err
+}
+
+// Decodes the ISO 5 six bit alphabet used for flight IDs
+func decodeISO5(value uint64) byte {
+	if value < 32 {
+		return byte(value) + '@'
+	}
+	return byte(value)
+}
+
+// Decodes a downlinked ADS-C report
+func DecodeADSC(payload []byte) (annotation Annotation, err error) {
+	r := &bitReader{data: payload}
+	annotation = Annotation{}
+	summary := []string{}
+	for r.remaining() >= 8 {
+		tag, _ := r.read(8)
+		if bits, ok := adscGroupBits[tag]; ok && r.remaining() < bits {
+			return annotation, errBitReaderShort
+		}
+		switch tag {
+		case 3, 6:
+			contract, _ := r.read(8)
+			annotation["adscReportType"] = ADSCReportTypes[tag]
+			annotation["adscContractNumber"] = contract
+			summary = append(summary, fmt.Sprintf("%s for contract %d", ADSCReportTypes[tag], contract))
+		case 4, 5:
+			// These carry variable length reasons we don't decode
+			annotation["adscReportType"] = ADSCReportTypes[tag]
+			summary = append(summary, ADSCReportTypes[tag])
+			r.pos = len(r.data) * 8
+		case 7, 9, 10, 18, 19, 20:
+			lat, _ := readADSCCoordinate(r)
+			lon, _ := readADSCCoordinate(r)
+			altitude, _ := readADSCAltitude(r)
+			timestamp, _ := r.read(15)
+			redundancy, _ := r.read(1)
+			accuracy, _ := r.read(3)
+			_, _ = r.read(1) // TCAS health
+			r.align()
+			annotation["adscReportType"] = ADSCReportTypes[tag]
+			annotation["adscEmergency"] = tag == 9
+			annotation["adscLatitude"] = lat
+			annotation["adscLongitude"] = lon
+			annotation["adscAltitudeFeet"] = altitude
+			annotation["adscSecondsPastHour"] = float64(timestamp) * 0.125
+			annotation["adscNavigationRedundancy"] = redundancy == 1
+			annotation["adscPositionAccuracy"] = accuracy
+			summary = append(summary, fmt.Sprintf("%s at %.4f,%.4f, %d ft", ADSCReportTypes[tag], lat, lon, altitude))
+		case 12:
+			flightID := make([]byte, 0, 8)
+			for i := 0; i < 8; i++ {
+				c, _ := r.read(6)
+				flightID = append(flightID, decodeISO5(c))
+			}
+			annotation["adscFlightID"] = strings.TrimSpace(string(flightID))
+			summary = append(summary, "flight "+strings.TrimSpace(string(flightID)))
+		case 13:
+			nextLat, _ := readADSCCoordinate(r)
+			nextLon, _ := readADSCCoordinate(r)
+			nextAltitude, _ := readADSCAltitude(r)
+			eta, _ := r.read(14)
+			followingLat, _ := readADSCCoordinate(r)
+			followingLon, _ := readADSCCoordinate(r)
+			followingAltitude, _ := readADSCAltitude(r)
+			r.align()
+			annotation["adscNextWaypointLatitude"] = nextLat
+			annotation["adscNextWaypointLongitude"] = nextLon
+			annotation["adscNextWaypointAltitudeFeet"] = nextAltitude
+			annotation["adscNextWaypointETASeconds"] = eta
+			annotation["adscFollowingWaypointLatitude"] = followingLat
+			annotation["adscFollowingWaypointLongitude"] = followingLon
+			annotation["adscFollowingWaypointAltitudeFeet"] = followingAltitude
+			summary = append(summary, fmt.Sprintf("next waypoint %.4f,%.4f at %d ft in %ds", nextLat, nextLon, nextAltitude, eta))
+		case 14:
+			_, _ = r.read(1) // track valid
+			track, _ := r.readSigned(12)
+			groundSpeed, _ := r.read(13)
+			verticalRate, _ := r.readSigned(12)
+			r.align()
+			trackDegrees := math.Mod(float64(track)*180/2048+360, 360)
+			annotation["adscTrueTrackDegrees"] = trackDegrees
+			annotation["adscGroundSpeedKnots"] = float64(groundSpeed) * 0.5
+			annotation["adscVerticalRateFeetPerMinute"] = verticalRate * 16
+			summary = append(summary, fmt.Sprintf("track %.0f, %.0f kt", trackDegrees, float64(groundSpeed)*0.5))
+		case 15:
+			_, _ = r.read(1) // heading valid
+			heading, _ := r.readSigned(12)
+			mach, _ := r.read(13)
+			verticalRate, _ := r.readSigned(12)
+			r.align()
+			annotation["adscTrueHeadingDegrees"] = math.Mod(float64(heading)*180/2048+360, 360)
+			annotation["adscMach"] = float64(mach) * 0.0005
+			annotation["adscAirVerticalRateFeetPerMinute"] = verticalRate * 16
+		case 16:
+			windSpeed, _ := r.read(9)
+			_, _ = r.read(1) // wind direction valid
+			windDirection, _ := r.readSigned(9)
+			temperature, _ := r.readSigned(12)
+			r.align()
+			annotation["adscWindSpeedKnots"] = float64(windSpeed) * 0.5
+			annotation["adscWindDirectionDegrees"] = math.Mod(float64(windDirection)*180/256+360, 360)
+			annotation["adscTemperatureC"] = float64(temperature) * 0.25
+		case 17:
+			address, _ := r.read(24)
+			annotation["adscICAOHex"] = fmt.Sprintf("%06x", address)
+		default:
+			annotation["arinc622DecodedText"] = "ADS-C " + strings.Join(summary, ", ")
+			return annotation, fmt.Errorf("unknown ADS-C tag %d", tag)
+		}
+	}
+	annotation["arinc622DecodedText"] = "ADS-C " + strings.Join(summary, ", ")
+	return annotation, nil
+}
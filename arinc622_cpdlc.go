@@ -0,0 +1,276 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Kinds of CPDLC element parameters we know how to read. Elements with any
+// other parameter get their template text but stop the decode, since the
+// length of what follows is unknown.
+const (
+	cpdlcParamAltitude = iota
+	cpdlcParamBeaconCode
+	cpdlcParamFreeText
+	cpdlcParamUnknown
+)
+
+type CPDLCElement struct {
+	Text   string
+	Params []int
+}
+
+// Common FANS-1/A uplink message elements, from RTCA DO-219
+var CPDLCUplinkElements = map[uint64]CPDLCElement{
+	0:   {"UNABLE", nil},
+	1:   {"STANDBY", nil},
+	2:   {"REQUEST DEFERRED", nil},
+	3:   {"ROGER", nil},
+	4:   {"AFFIRM", nil},
+	5:   {"NEGATIVE", nil},
+	6:   {"EXPECT [altitude]", []int{cpdlcParamAltitude}},
+	19:  {"MAINTAIN [altitude]", []int{cpdlcParamAltitude}},
+	20:  {"CLIMB TO AND MAINTAIN [altitude]", []int{cpdlcParamAltitude}},
+	23:  {"DESCEND TO AND MAINTAIN [altitude]", []int{cpdlcParamAltitude}},
+	36:  {"EXPEDITE CLIMB TO [altitude]", []int{cpdlcParamAltitude}},
+	37:  {"EXPEDITE DESCENT TO [altitude]", []int{cpdlcParamAltitude}},
+	38:  {"IMMEDIATELY CLIMB TO [altitude]", []int{cpdlcParamAltitude}},
+	39:  {"IMMEDIATELY DESCEND TO [altitude]", []int{cpdlcParamAltitude}},
+	74:  {"PROCEED DIRECT TO [position]", []int{cpdlcParamUnknown}},
+	79:  {"CLEARED TO [position] VIA [route clearance]", []int{cpdlcParamUnknown}},
+	80:  {"CLEARED [route clearance]", []int{cpdlcParamUnknown}},
+	106: {"MAINTAIN [speed]", []int{cpdlcParamUnknown}},
+	117: {"CONTACT [icao unit name] [frequency]", []int{cpdlcParamUnknown}},
+	120: {"MONITOR [icao unit name] [frequency]", []int{cpdlcParamUnknown}},
+	123: {"SQUAWK [beacon code]", []int{cpdlcParamBeaconCode}},
+	124: {"STOP SQUAWK", nil},
+	127: {"REPORT BACK ON ROUTE", nil},
+	133: {"REPORT PRESENT LEVEL", nil},
+	135: {"CONFIRM ASSIGNED LEVEL", nil},
+	148: {"WHEN CAN YOU ACCEPT [altitude]", []int{cpdlcParamAltitude}},
+	157: {"CHECK STUCK MICROPHONE [frequency]", []int{cpdlcParamUnknown}},
+	159: {"ERROR [error information]", []int{cpdlcParamUnknown}},
+	160: {"NEXT DATA AUTHORITY [icao facility designation]", []int{cpdlcParamUnknown}},
+	161: {"END SERVICE", nil},
+	169: {"[free text]", []int{cpdlcParamFreeText}},
+	179: {"SQUAWK IDENT", nil},
+	182: {"CONFIRM ATIS CODE", nil},
+}
+
+// Common FANS-1/A downlink message elements, from RTCA DO-219
+var CPDLCDownlinkElements = map[uint64]CPDLCElement{
+	0:  {"WILCO", nil},
+	1:  {"UNABLE", nil},
+	2:  {"STANDBY", nil},
+	3:  {"ROGER", nil},
+	4:  {"AFFIRM", nil},
+	5:  {"NEGATIVE", nil},
+	6:  {"REQUEST [altitude]", []int{cpdlcParamAltitude}},
+	9:  {"REQUEST CLIMB TO [altitude]", []int{cpdlcParamAltitude}},
+	10: {"REQUEST DESCENT TO [altitude]", []int{cpdlcParamAltitude}},
+	18: {"REQUEST [speed]", []int{cpdlcParamUnknown}},
+	20: {"REQUEST VOICE CONTACT", nil},
+	22: {"REQUEST DIRECT TO [position]", []int{cpdlcParamUnknown}},
+	27: {"REQUEST WEATHER DEVIATION UP TO [distance offset] [direction] OF ROUTE", []int{cpdlcParamUnknown}},
+	32: {"PRESENT LEVEL [altitude]", []int{cpdlcParamAltitude}},
+	34: {"PRESENT SPEED [speed]", []int{cpdlcParamUnknown}},
+	37: {"MAINTAINING [altitude]", []int{cpdlcParamAltitude}},
+	38: {"ASSIGNED LEVEL [altitude]", []int{cpdlcParamAltitude}},
+	48: {"POSITION REPORT [position report]", []int{cpdlcParamUnknown}},
+	55: {"PAN PAN PAN", nil},
+	56: {"MAYDAY MAYDAY MAYDAY", nil},
+	57: {"[remaining fuel] OF FUEL REMAINING AND [souls on board] SOULS ON BOARD", []int{cpdlcParamUnknown}},
+	58: {"CANCEL EMERGENCY", nil},
+	62: {"ERROR [error information]", []int{cpdlcParamUnknown}},
+	63: {"NOT CURRENT DATA AUTHORITY", nil},
+	65: {"DUE TO WEATHER", nil},
+	66: {"DUE TO AIRCRAFT PERFORMANCE", nil},
+	67: {"[free text]", []int{cpdlcParamFreeText}},
+	68: {"[free text]", []int{cpdlcParamFreeText}},
+	80: {"DEVIATING [distance offset] [direction] OF ROUTE", []int{cpdlcParamUnknown}},
+}
+
+// Element choices are indexes into 183 uplink and 81 downlink alternatives
+const (
+	cpdlcUplinkElementBits   = 8
+	cpdlcDownlinkElementBits = 7
+)
+
+// Reads a FANS-1/A Altitude choice
+func readCPDLCAltitude(r *bitReader) (string, error) {
+	choice, err := r.read(3)
+	if err != nil {
+		return "", err
+	}
+	var value uint64
+	switch choice {
+	case 0:
+		value, err = r.read(12)
+		return fmt.Sprintf("%d FT", value*10), err
+	case 1:
+		value, err = r.read(14)
+		return fmt.Sprintf("%d M", value), err
+	case 2:
+		value, err = r.read(12)
+		return fmt.Sprintf("%d FT QFE", value*10), err
+	case 3:
+		value, err = r.read(13)
+		return fmt.Sprintf("%d M QFE", value), err
+	case 4:
+		value, err = r.read(18)
+		return fmt.Sprintf("%d FT GNSS", value), err
+	case 5:
+		value, err = r.read(16)
+		return fmt.Sprintf("%d M GNSS", value), err
+	case 6:
+		value, err = r.read(10)
+		return fmt.Sprintf("FL%d", value+30), err
+	default:
+		value, err = r.read(11)
+		return fmt.Sprintf("%d M FL", (value+100)*10), err
+	}
+}
+
+// Reads a four digit octal transponder code
+func readCPDLCBeaconCode(r *bitReader) (string, error) {
+	code := ""
+	for i := 0; i < 4; i++ {
+		digit, err := r.read(3)
+		if err != nil {
+			return "", err
+		}
+		code += fmt.Sprint(digit)
+	}
+	return code, nil
+}
+
+// Reads an IA5String of 1 to 256 seven bit characters
+func readCPDLCFreeText(r *bitReader) (string, error) {
+	length, err := r.read(8)
+	if err != nil {
+		return "", err
+	}
+	text := make([]byte, 0, length+1)
+	for i := uint64(0); i <= length; i++ {
+		c, err := r.read(7)
+		if err != nil {
+			return "", err
+		}
+		text = append(text, byte(c))
+	}
+	return string(text), nil
+}
+
+// Reads one message element and fills in its template, returning the element
+// ID ("UM20"), the text and whether decoding can continue past it
+func readCPDLCElement(r *bitReader, direction string) (id, text string, complete bool, err error) {
+	elements, bits, prefix := CPDLCUplinkElements, cpdlcUplinkElementBits, "UM"
+	if direction == ACARSDirectionDownlink {
+		elements, bits, prefix = CPDLCDownlinkElements, cpdlcDownlinkElementBits, "DM"
+	}
+	index, err := r.read(bits)
+	if err != nil {
+		return "", "", false, err
+	}
+	id = fmt.Sprintf("%s%d", prefix, index)
+	element, known := elements[index]
+	if !known {
+		return id, id, false, nil
+	}
+	text = element.Text
+	for _, param := range element.Params {
+		var value string
+		switch param {
+		case cpdlcParamAltitude:
+			value, err = readCPDLCAltitude(r)
+		case cpdlcParamBeaconCode:
+			value, err = readCPDLCBeaconCode(r)
+		case cpdlcParamFreeText:
+			value, err = readCPDLCFreeText(r)
+		default:
+			return id, text, false, nil
+		}
+		if err != nil {
+			return id, text, false, err
+		}
+		start := strings.Index(text, "[")
+		end := strings.Index(text, "]")
+		if start >= 0 && end > start {
+			text = text[:start] + value + text[end+1:]
+		}
+	}
+	return id, text, true, nil
+}
+
+// Decodes a FANS-1/A CPDLC message. These are unaligned PER encoded: a header
+// with the message ID, optional reference and timestamp, then up to five
+// message elements.
+func DecodeCPDLC(payload []byte, direction string) (annotation Annotation, err error) {
+	r := &bitReader{data: payload}
+	annotation = Annotation{}
+	if len(payload) == 0 {
+		// Connect confirms and disconnects can be empty
+		annotation["arinc622DecodedText"] = "CPDLC"
+		return annotation, nil
+	}
+	hasExtraElements, err := r.read(1)
+	if err != nil {
+		return annotation, err
+	}
+	hasReference, _ := r.read(1)
+	hasTimestamp, _ := r.read(1)
+	messageID, err := r.read(6)
+	if err != nil {
+		return annotation, err
+	}
+	annotation["cpdlcMessageID"] = messageID
+	if hasReference == 1 {
+		reference, err := r.read(6)
+		if err != nil {
+			return annotation, err
+		}
+		annotation["cpdlcMessageReference"] = reference
+	}
+	if hasTimestamp == 1 {
+		hours, _ := r.read(5)
+		minutes, _ := r.read(6)
+		seconds, err := r.read(6)
+		if err != nil {
+			return annotation, err
+		}
+		annotation["cpdlcTimestamp"] = fmt.Sprintf("%02d:%02d:%02d", hours, minutes, seconds)
+	}
+
+	ids := []string{}
+	texts := []string{}
+	defer func() {
+		if len(ids) > 0 {
+			annotation["cpdlcElementID"] = strings.Join(ids, " ")
+			annotation["cpdlcText"] = strings.Join(texts, " ")
+			annotation["arinc622DecodedText"] = "CPDLC " + strings.Join(texts, " ")
+		}
+	}()
+	id, text, complete, err := readCPDLCElement(r, direction)
+	if id != "" {
+		ids = append(ids, id)
+		texts = append(texts, text)
+	}
+	if err != nil || !complete || hasExtraElements == 0 {
+		return annotation, err
+	}
+	extraElements, err := r.read(2)
+	if err != nil {
+		return annotation, err
+	}
+	for i := uint64(0); i <= extraElements; i++ {
+		id, text, complete, err := readCPDLCElement(r, direction)
+		if id != "" {
+			ids = append(ids, id)
+			texts = append(texts, text)
+		}
+		if err != nil || !complete {
+			return annotation, err
+		}
+	}
+	return annotation, nil
+}
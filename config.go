@@ -10,18 +10,108 @@ type Config struct {
 	AnnotateVDLM2                               bool    `env:"ANNOTATE_VDLM2"`
 	TAR1090URL                                  string  `env:"TAR1090_URL"`
 	TAR1090ReferenceGeolocation                 string  `env:"TAR1090_REFERENCE_GEOLOCATION"`
+	TAR1090PollIntervalSeconds                  int     `env:"TAR1090_POLL_INTERVAL_SECONDS"`
+	TAR1090MaxDataAgeSeconds                    int     `env:"TAR1090_MAX_DATA_AGE_SECONDS"`
+	SBSHost                                     string  `env:"SBS_HOST"`
+	SBSPort                                     int     `env:"SBS_PORT"`
+	ReadsbJSONHost                              string  `env:"READSB_JSON_HOST"`
+	ReadsbJSONPort                              int     `env:"READSB_JSON_PORT"`
+	AircraftStateMaxAgeSeconds                  int     `env:"AIRCRAFT_STATE_MAX_AGE_SECONDS"`
 	ACARSAnnotatorSelectedFields                string  `env:"ACARS_ANNOTATOR_SELECTED_FIELDS"`
 	ADSBExchangeAPIKey                          string  `env:"ADBSEXCHANGE_APIKEY"`
 	ADSBExchangeReferenceGeolocation            string  `env:"ADBSEXCHANGE_REFERENCE_GEOLOCATION"`
+	ADSBAPIProvider                             string  `env:"ADSB_API_PROVIDER"`
+	ADSBAPIURL                                  string  `env:"ADSB_API_URL"`
+	ADSBAPIKey                                  string  `env:"ADSB_API_KEY"`
+	ADSBAPIKeyHeader                            string  `env:"ADSB_API_KEY_HEADER"`
+	ADSBAPIQuery                                string  `env:"ADSB_API_QUERY"`
+	ADSBAPIRadiusNm                             int     `env:"ADSB_API_RADIUS_NM"`
 	OpenAIAPIKey                                string  `env:"FILTER_OPENAI_APIKEY"`
 	OpenAIPrompt                                string  `env:"FILTER_OPENAI_PROMPT"`
 	OpenAIModel                                 string  `env:"FILTER_OPENAI_MODEL"`
 	OpenAICustomPreamble                        string  `env:"FILTER_OPENAI_PREAMBLE"`
+	OpenAISystemPrompt                          string  `env:"FILTER_OPENAI_SYSTEM_PROMPT"`
+	OpenAIBaseURL                               string  `env:"FILTER_OPENAI_BASE_URL"`
+	OpenAIOrganization                          string  `env:"FILTER_OPENAI_ORGANIZATION"`
+	OpenAIAPIVersion                            string  `env:"FILTER_OPENAI_API_VERSION"`
+	OpenAIHeaders                               string  `env:"FILTER_OPENAI_HEADERS"`
+	OpenAITimeoutSeconds                        int     `env:"FILTER_OPENAI_TIMEOUT_SECONDS"`
+	OpenAIOnError                               string  `env:"FILTER_OPENAI_ON_ERROR"`
+	OpenAIOnTimeout                             string  `env:"FILTER_OPENAI_ON_TIMEOUT"`
+	OpenAIOnBlank                               string  `env:"FILTER_OPENAI_ON_BLANK"`
+	OpenAIOnUnparsable                          string  `env:"FILTER_OPENAI_ON_UNPARSABLE"`
+	OpenAIFallbackFilter                        string  `env:"FILTER_OPENAI_FALLBACK"`
+	OpenAIResponseFormat                        string  `env:"FILTER_OPENAI_RESPONSE_FORMAT"`
+	OpenAIOnLimit                               string  `env:"FILTER_OPENAI_ON_LIMIT"`
+	OpenAIRateLimitPerMinute                    float64 `env:"FILTER_OPENAI_RATE_LIMIT_PER_MINUTE"`
+	OpenAIDailyTokenBudget                      int64   `env:"FILTER_OPENAI_DAILY_TOKEN_BUDGET"`
+	OpenAIDailyCostBudget                       float64 `env:"FILTER_OPENAI_DAILY_COST_BUDGET"`
+	OpenAIInputCostPerMillionTokens             float64 `env:"FILTER_OPENAI_INPUT_COST_PER_MILLION_TOKENS"`
+	OpenAIOutputCostPerMillionTokens            float64 `env:"FILTER_OPENAI_OUTPUT_COST_PER_MILLION_TOKENS"`
 	OllamaURL                                   string  `env:"FILTER_OLLAMA_URL"`
 	OllamaPrompt                                string  `env:"FILTER_OLLAMA_PROMPT"`
 	OllamaSystemPrompt                          string  `env:"FILTER_OLLAMA_SYSTEM_PROMPT"`
 	OllamaSystemAssistant                       string  `env:"FILTER_OLLAMA_SYSTEM_ASSISTANT"`
 	OllamaModel                                 string  `env:"FILTER_OLLAMA_MODEL"`
+	OllamaTimeoutSeconds                        int     `env:"FILTER_OLLAMA_TIMEOUT_SECONDS"`
+	OllamaOnError                               string  `env:"FILTER_OLLAMA_ON_ERROR"`
+	OllamaOnTimeout                             string  `env:"FILTER_OLLAMA_ON_TIMEOUT"`
+	OllamaOnBlank                               string  `env:"FILTER_OLLAMA_ON_BLANK"`
+	OllamaOnUnparsable                          string  `env:"FILTER_OLLAMA_ON_UNPARSABLE"`
+	OllamaFallbackFilter                        string  `env:"FILTER_OLLAMA_FALLBACK"`
+	OllamaFormat                                string  `env:"FILTER_OLLAMA_FORMAT"`
+	OllamaOnLimit                               string  `env:"FILTER_OLLAMA_ON_LIMIT"`
+	OllamaRateLimitPerMinute                    float64 `env:"FILTER_OLLAMA_RATE_LIMIT_PER_MINUTE"`
+	OllamaDailyTokenBudget                      int64   `env:"FILTER_OLLAMA_DAILY_TOKEN_BUDGET"`
+	LLMExamplesFile                             string  `env:"FILTER_LLM_EXAMPLES_FILE"`
+	LLMMalformedRetries                         int     `env:"FILTER_LLM_MALFORMED_RETRIES"`
+	LLMCacheSize                                int     `env:"FILTER_LLM_CACHE_SIZE"`
+	LLMCacheTTLSeconds                          int     `env:"FILTER_LLM_CACHE_TTL_SECONDS"`
+	LLMCacheFile                                string  `env:"FILTER_LLM_CACHE_FILE"`
+	AnnotateACARSLabels                         bool    `env:"ANNOTATE_ACARS_LABELS"`
+	ACARSLabelAnnotatorSelectedFields           string  `env:"ACARS_LABEL_ANNOTATOR_SELECTED_FIELDS"`
+	AnnotateParsedText                          bool    `env:"ANNOTATE_PARSED_TEXT"`
+	ParsedTextAnnotatorSelectedFields           string  `env:"PARSED_TEXT_ANNOTATOR_SELECTED_FIELDS"`
+	AnnotateARINC622                            bool    `env:"ANNOTATE_ARINC622"`
+	ARINC622AnnotatorSelectedFields             string  `env:"ARINC622_ANNOTATOR_SELECTED_FIELDS"`
+	AircraftDBFile                              string  `env:"AIRCRAFT_DB_FILE"`
+	AircraftDBReloadSeconds                     int     `env:"AIRCRAFT_DB_RELOAD_SECONDS"`
+	AircraftDBAnnotatorSelectedFields           string  `env:"AIRCRAFT_DB_ANNOTATOR_SELECTED_FIELDS"`
+	AnnotateAirlines                            bool    `env:"ANNOTATE_AIRLINES"`
+	AirlineAnnotatorSelectedFields              string  `env:"AIRLINE_ANNOTATOR_SELECTED_FIELDS"`
+	AirportsFile                                string  `env:"AIRPORTS_FILE"`
+	AirportsNearestTypes                        string  `env:"AIRPORTS_NEAREST_TYPES"`
+	AirportAnnotatorSelectedFields              string  `env:"AIRPORT_ANNOTATOR_SELECTED_FIELDS"`
+	AnnotateICAOAddress                         bool    `env:"ANNOTATE_ICAO_ADDRESS"`
+	ICAOAddressAnnotatorSelectedFields          string  `env:"ICAO_ADDRESS_ANNOTATOR_SELECTED_FIELDS"`
+	AnnotateTrack                               bool    `env:"ANNOTATE_TRACK"`
+	TrackHistoryPoints                          int     `env:"TRACK_HISTORY_POINTS"`
+	TrackHistoryMaxAgeSeconds                   int     `env:"TRACK_HISTORY_MAX_AGE_SECONDS"`
+	TrackAnnotatorSelectedFields                string  `env:"TRACK_ANNOTATOR_SELECTED_FIELDS"`
+	AnnotateLookAngle                           bool    `env:"ANNOTATE_LOOK_ANGLE"`
+	StationGeolocation                          string  `env:"STATION_GEOLOCATION"`
+	StationAltitudeMeters                       float64 `env:"STATION_ALTITUDE_METERS"`
+	StationAntennaHeightMeters                  float64 `env:"STATION_ANTENNA_HEIGHT_METERS"`
+	LookAngleAnnotatorSelectedFields            string  `env:"LOOK_ANGLE_ANNOTATOR_SELECTED_FIELDS"`
+	AnnotateReception                           bool    `env:"ANNOTATE_RECEPTION"`
+	ReceptionSectorDegrees                      int     `env:"RECEPTION_SECTOR_DEGREES"`
+	ReceptionSignalBucketDB                     float64 `env:"RECEPTION_SIGNAL_BUCKET_DB"`
+	ReceptionRecentPositions                    int     `env:"RECEPTION_RECENT_POSITIONS"`
+	ReceptionStatsFile                          string  `env:"RECEPTION_STATS_FILE"`
+	ReceptionHTTPAddress                        string  `env:"RECEPTION_HTTP_ADDRESS"`
+	HTTPAddress                                 string  `env:"HTTP_ADDRESS"`
+	ReceptionAnnotatorSelectedFields            string  `env:"RECEPTION_ANNOTATOR_SELECTED_FIELDS"`
+	AnnotateLocation                            bool    `env:"ANNOTATE_LOCATION"`
+	GeoNamesCitiesFile                          string  `env:"GEONAMES_CITIES_FILE"`
+	GeoNamesAdmin1File                          string  `env:"GEONAMES_ADMIN1_FILE"`
+	GeoNamesCountryFile                         string  `env:"GEONAMES_COUNTRY_FILE"`
+	LocationMinPopulation                       int64   `env:"LOCATION_MIN_POPULATION"`
+	LocationCountryBoundariesFile               string  `env:"LOCATION_COUNTRY_BOUNDARIES_FILE"`
+	LocationAdminBoundariesFile                 string  `env:"LOCATION_ADMIN_BOUNDARIES_FILE"`
+	LocationAnnotatorSelectedFields             string  `env:"LOCATION_ANNOTATOR_SELECTED_FIELDS"`
+	AnnotateLLMClassification                   bool    `env:"ANNOTATE_LLM_CLASSIFICATION"`
+	LLMClassificationProvider                   string  `env:"LLM_CLASSIFICATION_PROVIDER"`
+	LLMAnnotatorSelectedFields                  string  `env:"LLM_ANNOTATOR_SELECTED_FIELDS"`
 	ADSBAnnotatorSelectedFields                 string  `env:"ADSB_ANNOTATOR_SELECTED_FIELDS"`
 	VDLM2AnnotatorSelectedFields                string  `env:"VDLM2_ANNOTATOR_SELECTED_FIELDS"`
 	TAR1090AnnotatorSelectedFields              string  `env:"TAR1090_ANNOTATOR_SELECTED_FIELDS"`
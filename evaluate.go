@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Tallies how a filter did against a labeled set
+type LLMEvaluation struct {
+	Provider       string
+	TruePositives  int
+	FalsePositives int
+	TrueNegatives  int
+	FalseNegatives int
+	Undecided      int
+}
+
+func (e LLMEvaluation) Precision() float64 {
+	if e.TruePositives+e.FalsePositives == 0 {
+		return 0
+	}
+	return float64(e.TruePositives) / float64(e.TruePositives+e.FalsePositives)
+}
+
+func (e LLMEvaluation) Recall() float64 {
+	if e.TruePositives+e.FalseNegatives == 0 {
+		return 0
+	}
+	return float64(e.TruePositives) / float64(e.TruePositives+e.FalseNegatives)
+}
+
+func (e LLMEvaluation) Accuracy() float64 {
+	total := e.TruePositives + e.FalsePositives + e.TrueNegatives + e.FalseNegatives + e.Undecided
+	if total == 0 {
+		return 0
+	}
+	return float64(e.TruePositives+e.TrueNegatives) / float64(total)
+}
+
+// Runs every labeled message through a filter and counts the results
+func EvaluateLLMFilter(provider string, filter TextFilterFunction, labeled []LLMExample) (e LLMEvaluation) {
+	e.Provider = provider
+	for i, example := range labeled {
+		decision, outcome := filter(example.Text)
+		log.Debugf("%s example %d: expected %t, got %t (%s)", provider, i+1, example.Decision, decision, outcome)
+		switch {
+		case outcome != FilterOutcomeDecision:
+			e.Undecided++
+		case decision && example.Decision:
+			e.TruePositives++
+		case decision && !example.Decision:
+			e.FalsePositives++
+		case !decision && !example.Decision:
+			e.TrueNegatives++
+		default:
+			e.FalseNegatives++
+		}
+	}
+	return e
+}
+
+func (e LLMEvaluation) Report(w io.Writer) {
+	fmt.Fprintf(w, "%s:\n", e.Provider)
+	fmt.Fprintf(w, "  true positives:  %d\n", e.TruePositives)
+	fmt.Fprintf(w, "  false positives: %d\n", e.FalsePositives)
+	fmt.Fprintf(w, "  true negatives:  %d\n", e.TrueNegatives)
+	fmt.Fprintf(w, "  false negatives: %d\n", e.FalseNegatives)
+	fmt.Fprintf(w, "  undecided:       %d\n", e.Undecided)
+	fmt.Fprintf(w, "  precision:       %.3f\n", e.Precision())
+	fmt.Fprintf(w, "  recall:          %.3f\n", e.Recall())
+	fmt.Fprintf(w, "  accuracy:        %.3f\n", e.Accuracy())
+}
+
+// Evaluates the configured prompts against a labeled file, for use as
+// `acars-annotator evaluate labeled.jsonl`
+func EvaluateLLMFilters(filePath string) {
+	labeled, err := LoadLLMExamples(filePath)
+	if err != nil {
+		log.Fatalf("error loading labeled messages: %s", err)
+	}
+	ConfigureLLMExamples()
+
+	evaluated := false
+	if config.OpenAIAPIKey != "" || config.OpenAIBaseURL != "" {
+		EvaluateLLMFilter("openai", OpenAIDecision, labeled).Report(os.Stdout)
+		evaluated = true
+	}
+	if config.OllamaURL != "" {
+		EvaluateLLMFilter("ollama", OllamaDecision, labeled).Report(os.Stdout)
+		evaluated = true
+	}
+	if !evaluated {
+		log.Fatal("no llm filters are configured to evaluate")
+	}
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultLLMCacheTTLSeconds = 3600
+	llmCacheSaveInterval      = time.Minute
+)
+
+// Remembers decisions from LLM filters and classifications so identical
+// messages don't cost another API call
+type LLMCache struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	entries map[string]*list.Element
+	order   *list.List
+	dirty   bool
+}
+
+type LLMCacheEntry struct {
+	Key            string                     `json:"key"`
+	Decision       bool                       `json:"decision"`
+	Classification *LLMClassificationResponse `json:"classification,omitempty"`
+	Expires        time.Time                  `json:"expires"`
+}
+
+var llmCache *LLMCache
+
+func NewLLMCache(size int, ttl time.Duration) *LLMCache {
+	return &LLMCache{
+		size:    size,
+		ttl:     ttl,
+		entries: map[string]*list.Element{},
+		order:   list.New(),
+	}
+}
+
+// Sets up the shared cache from config, loading it from disk if configured
+func ConfigureLLMCache() {
+	size := config.LLMCacheSize
+	if size <= 0 {
+		return
+	}
+	ttl := config.LLMCacheTTLSeconds
+	if ttl <= 0 {
+		ttl = defaultLLMCacheTTLSeconds
+	}
+	llmCache = NewLLMCache(size, time.Duration(ttl)*time.Second)
+	if config.LLMCacheFile != "" {
+		llmCache.Load(config.LLMCacheFile)
+		go llmCache.SaveEvery(config.LLMCacheFile, llmCacheSaveInterval)
+	}
+	log.Infof("llm filter cache enabled, size %d, ttl %ds", size, ttl)
+}
+
+// Collapses whitespace so padded or re-wrapped copies of a message share a
+// key. Case is kept since it can change what a message means to the model.
+func NormalizeMessageText(m string) string {
+	return strings.Join(strings.Fields(m), " ")
+}
+
+func LLMCacheKey(provider, model, prompt, m string) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{provider, model, prompt, NormalizeMessageText(m)}, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+func (c *LLMCache) Get(key string) (decision bool, ok bool) {
+	entry, ok := c.get(key)
+	if !ok {
+		return false, false
+	}
+	return entry.Decision, true
+}
+
+func (c *LLMCache) GetClassification(key string) (classification LLMClassificationResponse, ok bool) {
+	entry, ok := c.get(key)
+	if !ok || entry.Classification == nil {
+		return classification, false
+	}
+	return *entry.Classification, true
+}
+
+func (c *LLMCache) get(key string) (*LLMCacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*LLMCacheEntry)
+	if time.Now().After(entry.Expires) {
+		c.order.Remove(element)
+		delete(c.entries, key)
+		c.dirty = true
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	return entry, true
+}
+
+func (c *LLMCache) Put(key string, decision bool) {
+	c.put(&LLMCacheEntry{Key: key, Decision: decision, Expires: time.Now().Add(c.ttl)})
+}
+
+func (c *LLMCache) PutClassification(key string, classification LLMClassificationResponse) {
+	c.put(&LLMCacheEntry{Key: key, Classification: &classification, Expires: time.Now().Add(c.ttl)})
+}
+
+func (c *LLMCache) put(entry *LLMCacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[entry.Key]; ok {
+		element.Value = entry
+		c.order.MoveToFront(element)
+	} else {
+		c.entries[entry.Key] = c.order.PushFront(entry)
+	}
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*LLMCacheEntry).Key)
+	}
+	c.dirty = true
+}
+
+// Wraps a filter so only real decisions are cached, errors and the like are
+// retried next time
+func (c *LLMCache) Wrap(provider, model, prompt string, filter TextFilterFunction) TextFilterFunction {
+	if c == nil {
+		return filter
+	}
+	return func(m string) (bool, string) {
+		key := LLMCacheKey(provider, model, prompt, m)
+		if decision, ok := c.Get(key); ok {
+			metrics.Increment("llmcache." + provider + ".hit")
+			log.Debugf("%s decision for message found in cache: %t", provider, decision)
+			return decision, FilterOutcomeDecision
+		}
+		metrics.Increment("llmcache." + provider + ".miss")
+		decision, outcome := filter(m)
+		if outcome == FilterOutcomeDecision {
+			c.Put(key, decision)
+		}
+		return decision, outcome
+	}
+}
+
+// Loads unexpired entries from a file relative to $HOME
+func (c *LLMCache) Load(filePath string) {
+	contents := ReadFile(filePath)
+	if len(contents) == 0 {
+		return
+	}
+	var entries []LLMCacheEntry
+	if err := json.Unmarshal(contents, &entries); err != nil {
+		log.Warnf("error reading llm cache file, starting empty: %s", err)
+		return
+	}
+	now := time.Now()
+	// Entries are saved most recent first, so insert them in reverse
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].Expires.After(now) {
+			c.put(&entries[i])
+		}
+	}
+	c.mu.Lock()
+	c.dirty = false
+	log.Infof("loaded %d entries into llm cache", c.order.Len())
+	c.mu.Unlock()
+}
+
+// Saves the cache to a file relative to $HOME if it has changed
+func (c *LLMCache) Save(filePath string) {
+	c.mu.Lock()
+	if !c.dirty {
+		c.mu.Unlock()
+		return
+	}
+	entries := make([]LLMCacheEntry, 0, c.order.Len())
+	for element := c.order.Front(); element != nil; element = element.Next() {
+		entries = append(entries, *element.Value.(*LLMCacheEntry))
+	}
+	c.dirty = false
+	c.mu.Unlock()
+
+	contents, err := json.Marshal(entries)
+	if err != nil {
+		log.Errorf("error encoding llm cache: %s", err)
+		return
+	}
+	WriteFile(filePath, contents)
+	log.Debugf("saved %d llm cache entries", len(entries))
+}
+
+func (c *LLMCache) SaveEvery(filePath string, interval time.Duration) {
+	for range time.Tick(interval) {
+		c.Save(filePath)
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLLMCacheKey(t *testing.T) {
+	base := LLMCacheKey("openai", "gpt-4o", "prompt", "POS N4012.3W07412.5")
+	tests := []struct {
+		name                          string
+		provider, model, prompt, text string
+		same                          bool
+	}{
+		{"identical", "openai", "gpt-4o", "prompt", "POS N4012.3W07412.5", true},
+		{"case", "openai", "gpt-4o", "prompt", "pos n4012.3w07412.5", false},
+		{"spacing", "openai", "gpt-4o", "prompt", "POS  N4012.3W07412.5", true},
+		{"padding and line breaks", "openai", "gpt-4o", "prompt", " POS\r\nN4012.3W07412.5 \n", true},
+		{"provider", "ollama", "gpt-4o", "prompt", "POS N4012.3W07412.5", false},
+		{"model", "openai", "gpt-4o-mini", "prompt", "POS N4012.3W07412.5", false},
+		{"prompt", "openai", "gpt-4o", "other prompt", "POS N4012.3W07412.5", false},
+		// Fields can't run together into the same key
+		{"boundaries", "openai", "gpt-4o", "promptPOS", "N4012.3W07412.5", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := LLMCacheKey(test.provider, test.model, test.prompt, test.text) == base; got != test.same {
+				t.Errorf("same key: got %t, want %t", got, test.same)
+			}
+		})
+	}
+}
+
+func TestLLMCacheEviction(t *testing.T) {
+	c := NewLLMCache(2, time.Hour)
+	c.Put("a", true)
+	c.Put("b", false)
+	// Reading "a" makes "b" the least recently used
+	c.Get("a")
+	c.Put("c", true)
+	if _, ok := c.Get("b"); ok {
+		t.Error("least recently used entry wasn't evicted")
+	}
+	if decision, ok := c.Get("a"); !ok || !decision {
+		t.Errorf("got %t %t for a", decision, ok)
+	}
+
+	expired := NewLLMCache(2, -time.Second)
+	expired.Put("a", true)
+	if _, ok := expired.Get("a"); ok {
+		t.Error("expired entry returned")
+	}
+}
+
+func TestLLMCacheWrap(t *testing.T) {
+	c := NewLLMCache(10, time.Hour)
+	calls := 0
+	filter := c.Wrap("openai", "gpt-4o", "prompt", func(m string) (bool, string) {
+		calls++
+		if m == "error" {
+			return false, FilterOutcomeError
+		}
+		return true, FilterOutcomeDecision
+	})
+	for _, m := range []string{"POS", " POS\n", "pos", "error", "error"} {
+		filter(m)
+	}
+	// "POS" once, "pos" once, and errors every time
+	if calls != 4 {
+		t.Errorf("filter called %d times, want 4", calls)
+	}
+
+	var disabled *LLMCache
+	calls = 0
+	filter = disabled.Wrap("openai", "gpt-4o", "prompt", func(m string) (bool, string) {
+		calls++
+		return true, FilterOutcomeDecision
+	})
+	filter("POS")
+	filter("POS")
+	if calls != 2 {
+		t.Errorf("disabled cache called the filter %d times, want 2", calls)
+	}
+}
+
+func TestConfigureLLMCacheOptIn(t *testing.T) {
+	previous, previousCache := config, llmCache
+	t.Cleanup(func() { config, llmCache = previous, previousCache })
+	for size, want := range map[int]bool{0: false, -1: false, 5: true} {
+		llmCache = nil
+		config.LLMCacheSize, config.LLMCacheFile = size, ""
+		ConfigureLLMCache()
+		if got := llmCache != nil; got != want {
+			t.Errorf("size %d: enabled %t, want %t", size, got, want)
+		}
+	}
+}
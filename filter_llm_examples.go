@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// A labeled message, used both as a few-shot example and for evaluation
+type LLMExample struct {
+	Text      string `json:"text"`
+	Decision  bool   `json:"decision"`
+	Reasoning string `json:"reasoning"`
+}
+
+var (
+	llmExamples       = []LLMExample{}
+	llmExamplesDigest string
+)
+
+// Loads the few-shot examples, if configured
+func ConfigureLLMExamples() {
+	if config.LLMExamplesFile == "" {
+		return
+	}
+	// Relative to $HOME like the other files the filters keep
+	contents := ReadFile(config.LLMExamplesFile)
+	if len(contents) == 0 {
+		log.Fatalf("llm examples file %s is missing or empty", config.LLMExamplesFile)
+	}
+	examples, err := ParseLLMExamples(contents)
+	if err != nil {
+		log.Fatalf("error loading llm examples: %s", err)
+	}
+	llmExamples = examples
+	// Changing the examples changes decisions, so they're part of cache keys
+	encoded, _ := json.Marshal(examples)
+	sum := sha256.Sum256(encoded)
+	llmExamplesDigest = hex.EncodeToString(sum[:])
+	log.Infof("loaded %d llm filter examples", len(examples))
+}
+
+// Reads labeled examples from a path given on the command line
+func LoadLLMExamples(filePath string) (examples []LLMExample, err error) {
+	contents, err := os.ReadFile(filePath)
+	if err != nil {
+		return examples, err
+	}
+	return ParseLLMExamples(contents)
+}
+
+// Parses labeled examples from either a JSON array or one JSON object per
+// line
+func ParseLLMExamples(contents []byte) (examples []LLMExample, err error) {
+	contents = bytes.TrimSpace(contents)
+	if bytes.HasPrefix(contents, []byte("[")) {
+		err = json.Unmarshal(contents, &examples)
+		return examples, err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(contents))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var example LLMExample
+		if err := json.Unmarshal(scanner.Bytes(), &example); err != nil {
+			return examples, fmt.Errorf("line %d: %w", line, err)
+		}
+		examples = append(examples, example)
+	}
+	return examples, scanner.Err()
+}
+
+// The reply we'd want from the model for this example
+func (e LLMExample) Response() string {
+	response, _ := json.Marshal(OpenAIResponse{Decision: e.Decision, Reasoning: e.Reasoning})
+	return string(response)
+}
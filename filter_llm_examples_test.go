@@ -0,0 +1,74 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseLLMExamples(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		want     int
+		wantErr  bool
+	}{
+		{"json lines", "{\"text\": \"A\", \"decision\": true}\n\n{\"text\": \"B\"}\n", 2, false},
+		{"json array", ` [{"text": "A", "decision": true}, {"text": "B"}]`, 2, false},
+		{"bad line", "{\"text\": \"A\"}\nnot json\n", 1, true},
+		{"empty", "", 0, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			examples, err := ParseLLMExamples([]byte(test.contents))
+			if len(examples) != test.want || (err != nil) != test.wantErr {
+				t.Errorf("got %d examples, error %v", len(examples), err)
+			}
+		})
+	}
+}
+
+func TestConfigureLLMExamplesFromHome(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	contents := `{"text": "REQUEST MEDICAL ASSISTANCE", "decision": true, "reasoning": "written by a person"}`
+	if err := os.WriteFile(filepath.Join(home, "examples.jsonl"), []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	previous, previousExamples, previousDigest := config, llmExamples, llmExamplesDigest
+	t.Cleanup(func() { config, llmExamples, llmExamplesDigest = previous, previousExamples, previousDigest })
+	config.LLMExamplesFile = "examples.jsonl"
+
+	ConfigureLLMExamples()
+	if len(llmExamples) != 1 || llmExamplesDigest == "" {
+		t.Fatalf("got %d examples, digest %q", len(llmExamples), llmExamplesDigest)
+	}
+	if got, want := llmExamples[0].Response(), `{"decision":true,"reasoning":"written by a person"}`; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestEvaluateLLMFilter(t *testing.T) {
+	labeled := []LLMExample{
+		{Text: "keep", Decision: true},
+		{Text: "keep", Decision: false},
+		{Text: "drop", Decision: false},
+		{Text: "drop", Decision: true},
+		{Text: "error", Decision: true},
+	}
+	e := EvaluateLLMFilter("test", func(m string) (bool, string) {
+		if m == "error" {
+			return false, FilterOutcomeError
+		}
+		return m == "keep", FilterOutcomeDecision
+	}, labeled)
+	if e.TruePositives != 1 || e.FalsePositives != 1 || e.TrueNegatives != 1 || e.FalseNegatives != 1 || e.Undecided != 1 {
+		t.Errorf("got %+v", e)
+	}
+	if e.Precision() != 0.5 || e.Recall() != 0.5 || e.Accuracy() != 0.4 {
+		t.Errorf("got precision %f, recall %f, accuracy %f", e.Precision(), e.Recall(), e.Accuracy())
+	}
+	if (LLMEvaluation{}).Accuracy() != 0 {
+		t.Error("an empty evaluation should score 0")
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Keeps LLM filters under a request rate and a daily token/cost budget
+type LLMLimiter struct {
+	mu                   sync.Mutex
+	name                 string
+	ratePerMinute        float64
+	bucket               float64
+	lastRefill           time.Time
+	dailyTokenBudget     int64
+	dailyCostBudget      float64
+	inputCostPerMillion  float64
+	outputCostPerMillion float64
+	day                  string
+	tokensToday          int64
+	costToday            float64
+}
+
+var (
+	openAILimiter *LLMLimiter
+	ollamaLimiter *LLMLimiter
+)
+
+// Sets up the OpenAI and Ollama limiters from config if any limit is set
+func ConfigureLLMLimits() {
+	if config.OpenAIRateLimitPerMinute > 0 || config.OpenAIDailyTokenBudget > 0 || config.OpenAIDailyCostBudget > 0 {
+		openAILimiter = NewLLMLimiter("openai", config.OpenAIRateLimitPerMinute,
+			config.OpenAIDailyTokenBudget, config.OpenAIDailyCostBudget,
+			config.OpenAIInputCostPerMillionTokens, config.OpenAIOutputCostPerMillionTokens)
+		log.Infof("openai limits enabled, %.1f requests per minute, %d tokens per day, $%.2f per day",
+			config.OpenAIRateLimitPerMinute, config.OpenAIDailyTokenBudget, config.OpenAIDailyCostBudget)
+	}
+	if config.OllamaRateLimitPerMinute > 0 || config.OllamaDailyTokenBudget > 0 {
+		ollamaLimiter = NewLLMLimiter("ollama", config.OllamaRateLimitPerMinute,
+			config.OllamaDailyTokenBudget, 0, 0, 0)
+		log.Infof("ollama limits enabled, %.1f requests per minute, %d tokens per day",
+			config.OllamaRateLimitPerMinute, config.OllamaDailyTokenBudget)
+	}
+}
+
+func NewLLMLimiter(name string, ratePerMinute float64, dailyTokenBudget int64, dailyCostBudget, inputCostPerMillion, outputCostPerMillion float64) *LLMLimiter {
+	return &LLMLimiter{
+		name:                 name,
+		ratePerMinute:        ratePerMinute,
+		bucket:               max(ratePerMinute, 1),
+		lastRefill:           time.Now(),
+		dailyTokenBudget:     dailyTokenBudget,
+		dailyCostBudget:      dailyCostBudget,
+		inputCostPerMillion:  inputCostPerMillion,
+		outputCostPerMillion: outputCostPerMillion,
+		day:                  time.Now().UTC().Format(time.DateOnly),
+	}
+}
+
+// Starts a new budget day if the date (UTC) has changed, must hold the lock
+func (l *LLMLimiter) rollover() {
+	today := time.Now().UTC().Format(time.DateOnly)
+	if today != l.day {
+		log.Infof("%s usage for %s: %d tokens, $%.4f", l.name, l.day, l.tokensToday, l.costToday)
+		l.day = today
+		l.tokensToday = 0
+		l.costToday = 0
+	}
+}
+
+// Returns false and the reason if a request isn't allowed right now
+func (l *LLMLimiter) Allow() (ok bool, reason string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rollover()
+	if l.dailyTokenBudget > 0 && l.tokensToday >= l.dailyTokenBudget {
+		return false, "token_budget"
+	}
+	if l.dailyCostBudget > 0 && l.costToday >= l.dailyCostBudget {
+		return false, "cost_budget"
+	}
+	if l.ratePerMinute > 0 {
+		now := time.Now()
+		capacity := max(l.ratePerMinute, 1)
+		l.bucket = min(capacity, l.bucket+now.Sub(l.lastRefill).Minutes()*l.ratePerMinute)
+		l.lastRefill = now
+		if l.bucket < 1 {
+			return false, "rate"
+		}
+		l.bucket--
+	}
+	return true, ""
+}
+
+// Records tokens used by a request
+func (l *LLMLimiter) Record(promptTokens, completionTokens int64) {
+	if l == nil {
+		return
+	}
+	cost := (float64(promptTokens)*l.inputCostPerMillion + float64(completionTokens)*l.outputCostPerMillion) / 1e6
+	l.mu.Lock()
+	l.rollover()
+	l.tokensToday += promptTokens + completionTokens
+	l.costToday += cost
+	tokensToday, costToday := l.tokensToday, l.costToday
+	l.mu.Unlock()
+
+	metrics.Add(fmt.Sprintf("llm.%s.tokens.prompt", l.name), promptTokens)
+	metrics.Add(fmt.Sprintf("llm.%s.tokens.completion", l.name), completionTokens)
+	metrics.Add(fmt.Sprintf("llm.%s.cost_microdollars", l.name), int64(cost*1e6))
+	log.Debugf("%s usage today: %d tokens, $%.4f", l.name, tokensToday, costToday)
+}
+
+// Takes one API call from the limits, logging and counting it either way.
+// Retries call this again since each one is charged separately.
+func (l *LLMLimiter) Call() bool {
+	if l == nil {
+		return true
+	}
+	if ok, reason := l.Allow(); !ok {
+		log.Warnf("%s is over its %s limit, not calling it", l.name, reason)
+		metrics.Increment(fmt.Sprintf("llm.%s.limited.%s", l.name, reason))
+		return false
+	}
+	metrics.Increment(fmt.Sprintf("llm.%s.calls", l.name))
+	return true
+}
+
+// Wraps a filter so it reports FilterOutcomeLimited instead of calling out
+// when over a limit. Blank messages never call out, so they aren't charged.
+func (l *LLMLimiter) Wrap(filter TextFilterFunction) TextFilterFunction {
+	if l == nil {
+		return filter
+	}
+	return func(m string) (bool, string) {
+		if blankMessageRegex.MatchString(m) {
+			return filter(m)
+		}
+		if !l.Call() {
+			return false, FilterOutcomeLimited
+		}
+		return filter(m)
+	}
+}
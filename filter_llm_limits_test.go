@@ -0,0 +1,98 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLLMLimiterRate(t *testing.T) {
+	l := NewLLMLimiter("test", 2, 0, 0, 0, 0)
+	for i := 0; i < 2; i++ {
+		if ok, reason := l.Allow(); !ok {
+			t.Fatalf("call %d limited by %s", i+1, reason)
+		}
+	}
+	if ok, reason := l.Allow(); ok || reason != "rate" {
+		t.Errorf("got %t %q, want rate limited", ok, reason)
+	}
+	// Half a minute at 2 per minute refills one call
+	l.lastRefill = l.lastRefill.Add(-30 * time.Second)
+	if ok, _ := l.Allow(); !ok {
+		t.Error("expected a call after the bucket refilled")
+	}
+}
+
+func TestLLMLimiterBudgets(t *testing.T) {
+	tests := []struct {
+		name               string
+		tokenBudget        int64
+		costBudget         float64
+		prompt, completion int64
+		wantOK             bool
+		wantReason         string
+	}{
+		{"under budgets", 1000, 1, 100, 100, true, ""},
+		{"token budget", 1000, 0, 900, 100, false, "token_budget"},
+		// $10 and $30 per million tokens
+		{"cost budget", 0, 0.01, 500, 200, false, "cost_budget"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			l := NewLLMLimiter("test", 0, test.tokenBudget, test.costBudget, 10, 30)
+			l.Record(test.prompt, test.completion)
+			if ok, reason := l.Allow(); ok != test.wantOK || reason != test.wantReason {
+				t.Errorf("got %t %q, want %t %q", ok, reason, test.wantOK, test.wantReason)
+			}
+		})
+	}
+}
+
+func TestLLMLimiterRollover(t *testing.T) {
+	l := NewLLMLimiter("test", 0, 100, 0, 0, 0)
+	l.Record(100, 0)
+	if ok, _ := l.Allow(); ok {
+		t.Fatal("expected the token budget to be spent")
+	}
+	l.day = "2000-01-01"
+	if ok, reason := l.Allow(); !ok {
+		t.Errorf("limited by %s after the day changed", reason)
+	}
+}
+
+func TestLLMLimiterWrap(t *testing.T) {
+	l := NewLLMLimiter("test", 1, 0, 0, 0, 0)
+	calls := 0
+	filter := l.Wrap(func(m string) (bool, string) {
+		calls++
+		if blankMessageRegex.MatchString(m) {
+			return false, FilterOutcomeBlank
+		}
+		return true, FilterOutcomeDecision
+	})
+
+	// Blank messages don't use up the one call a minute
+	if _, outcome := filter("   "); outcome != FilterOutcomeBlank {
+		t.Errorf("blank message got %q", outcome)
+	}
+	if _, outcome := filter("POS N4012.3W07412.5"); outcome != FilterOutcomeDecision {
+		t.Errorf("first message got %q", outcome)
+	}
+	if _, outcome := filter("POS N4012.3W07412.5"); outcome != FilterOutcomeLimited {
+		t.Errorf("second message got %q, want limited", outcome)
+	}
+	if calls != 2 {
+		t.Errorf("filter called %d times, want 2", calls)
+	}
+	// A retry is charged like any other call
+	if l.Call() {
+		t.Error("retry wasn't limited")
+	}
+}
+
+func TestNilLLMLimiter(t *testing.T) {
+	var l *LLMLimiter
+	if !l.Call() {
+		t.Error("a nil limiter shouldn't limit")
+	}
+	l.Record(1, 1)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+const defaultLLMMalformedRetries = 1
+
+// JSON schema for the {"decision": boolean, "reasoning": string} response
+// every LLM filter asks for
+var LLMDecisionSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"decision":  map[string]any{"type": "boolean"},
+		"reasoning": map[string]any{"type": "string"},
+	},
+	"required":             []string{"decision", "reasoning"},
+	"additionalProperties": false,
+}
+
+// Unmarshals an LLM reply into v, digging the first JSON object out of it if
+// the model wrapped it in backticks or prose
+func ParseLLMJSON(content string, v any) error {
+	err := json.Unmarshal([]byte(content), v)
+	if err == nil {
+		return nil
+	}
+	object, ok := ExtractJSONObject(content)
+	if !ok {
+		return err
+	}
+	return json.Unmarshal([]byte(object), v)
+}
+
+// Returns the first balanced {...} in s, skipping braces inside strings
+func ExtractJSONObject(s string) (string, bool) {
+	start := strings.Index(s, "{")
+	for start != -1 {
+		depth, inString, escaped := 0, false, false
+		for i := start; i < len(s); i++ {
+			c := s[i]
+			switch {
+			case escaped:
+				escaped = false
+			case inString && c == '\\':
+				escaped = true
+			case c == '"':
+				inString = !inString
+			case inString:
+			case c == '{':
+				depth++
+			case c == '}':
+				depth--
+				if depth == 0 {
+					if json.Valid([]byte(s[start : i+1])) {
+						return s[start : i+1], true
+					}
+					i = len(s)
+				}
+			}
+		}
+		next := strings.Index(s[start+1:], "{")
+		if next == -1 {
+			break
+		}
+		start += next + 1
+	}
+	return "", false
+}
+
+// How many times to ask again when the reply can't be parsed
+func LLMMalformedRetries() int {
+	if config.LLMMalformedRetries < 0 {
+		return 0
+	}
+	if config.LLMMalformedRetries == 0 {
+		return defaultLLMMalformedRetries
+	}
+	return config.LLMMalformedRetries
+}
@@ -3,10 +3,12 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
-	"regexp"
+	"strings"
+	"time"
 
 	api "github.com/ollama/ollama/api"
 	log "github.com/sirupsen/logrus"
@@ -39,23 +41,26 @@ type OllamaResponse struct {
 
 // Return true if a message passes a filter, false otherwise
 func OllamaFilter(m string) bool {
+	prompt := config.OllamaSystemPrompt + config.OllamaPrompt + llmExamplesDigest
+	return OllamaFilterPolicy().Evaluate(m, llmCache.Wrap("ollama", config.OllamaModel, prompt, ollamaLimiter.Wrap(OllamaDecision)))
+}
+
+// Asks Ollama about a message, returning the decision and the outcome
+func OllamaDecision(m string) (decision bool, outcome string) {
 	if config.OllamaModel == "" {
 		log.Warn("Ollama model not specified, this is required to use the ollama filter")
-		return true
+		return false, FilterOutcomeError
 	}
-	if match, err := regexp.Match(`\S*`, []byte(m)); !match || err != nil {
+	if blankMessageRegex.MatchString(m) {
 		log.Info("message was blank, filtering without calling Ollama")
-		return true
+		return false, FilterOutcomeBlank
 	}
 	url, err := url.Parse(config.OllamaURL)
 	if err != nil {
 		log.Fatalf("Ollama url could not be parsed: %s", err)
-		return true
+		return false, FilterOutcomeError
 	}
 	client := api.NewClient(url, &http.Client{})
-	if err != nil {
-		log.Fatalf("error initializing Ollama: %s", err)
-	}
 
 	if config.OllamaSystemPrompt != "" {
 		OllamaSystemPrompt = config.OllamaSystemPrompt
@@ -65,32 +70,82 @@ func OllamaFilter(m string) bool {
 			Role:    "system",
 			Content: OllamaSystemPrompt,
 		},
-		{
-			Role:    "user",
-			Content: fmt.Sprintf(OllamaSystemPrompt, config.OllamaPrompt, m),
-		},
 	}
+	for _, example := range llmExamples {
+		messages = append(messages,
+			api.Message{
+				Role:    "user",
+				Content: fmt.Sprintf(OllamaSystemPrompt, config.OllamaPrompt, example.Text),
+			},
+			api.Message{
+				Role:    "assistant",
+				Content: example.Response(),
+			},
+		)
+	}
+	messages = append(messages, api.Message{
+		Role:    "user",
+		Content: fmt.Sprintf(OllamaSystemPrompt, config.OllamaPrompt, m),
+	})
 
 	ctx := context.Background()
+	if config.OllamaTimeoutSeconds > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(config.OllamaTimeoutSeconds)*time.Second)
+		defer cancel()
+	}
+	stream := false
 	req := &api.ChatRequest{
 		Model:    config.OllamaModel,
 		Messages: messages,
+		Stream:   &stream,
+		Format:   OllamaFormat(),
 	}
 
-	var r OllamaResponse
-	respFunc := func(resp api.ChatResponse) error {
-		err = json.Unmarshal([]byte(resp.Message.Content), &r)
+	for attempt := 0; attempt <= LLMMalformedRetries(); attempt++ {
+		// The first call was charged by the limiter's wrapper
+		if attempt > 0 && !ollamaLimiter.Call() {
+			return false, FilterOutcomeLimited
+		}
+		var content string
+		respFunc := func(resp api.ChatResponse) error {
+			content += resp.Message.Content
+			if resp.Done {
+				ollamaLimiter.Record(int64(resp.PromptEvalCount), int64(resp.EvalCount))
+			}
+			return nil
+		}
+
+		err = client.Chat(ctx, req, respFunc)
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Errorf("timed out waiting for Ollama: %s", err)
+			return false, FilterOutcomeTimeout
+		}
 		if err != nil {
-			return err
+			log.Errorf("error using Ollama: %s", err)
+			return false, FilterOutcomeError
 		}
-		return nil
+		var r OllamaResponse
+		err = ParseLLMJSON(content, &r)
+		if err != nil {
+			log.Warnf("error unmarshaling response from Ollama (attempt %d): %s", attempt+1, err)
+			continue
+		}
+		log.Debugf("Ollama response: %+v", r)
+		return r.Decision, FilterOutcomeDecision
 	}
+	return false, FilterOutcomeUnparsable
+}
 
-	err = client.Chat(ctx, req, respFunc)
-	if err != nil {
-		log.Errorf("error using Ollama: %s", err)
-		return true
+// Returns the format to ask Ollama for, "json" by default
+func OllamaFormat() json.RawMessage {
+	switch strings.ToLower(config.OllamaFormat) {
+	case "", "json":
+		return json.RawMessage(`"json"`)
+	case "schema":
+		schema, _ := json.Marshal(LLMDecisionSchema)
+		return schema
+	default:
+		return nil
 	}
-	log.Debugf("Ollama response: %+v", r)
-	return r.Decision
 }
@@ -2,9 +2,13 @@ package main
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"regexp"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/openai/openai-go"
 	"github.com/openai/openai-go/option"
@@ -38,40 +42,200 @@ type OpenAIResponse struct {
 
 // Return true if a message passes a filter, false otherwise
 func OpenAIFilter(m string) bool {
+	prompt := config.OpenAISystemPrompt + config.OpenAICustomPreamble + config.OpenAIPrompt + llmExamplesDigest
+	return OpenAIFilterPolicy().Evaluate(m, llmCache.Wrap("openai", OpenAIModel(), prompt, openAILimiter.Wrap(OpenAIDecision)))
+}
+
+// Returns the configured model, or the default
+func OpenAIModel() string {
+	if config.OpenAIModel != "" {
+		return config.OpenAIModel
+	}
+	return openai.ChatModelGPT4o
+}
+
+// Builds a client for OpenAI or any OpenAI-compatible server (vLLM,
+// llama.cpp, LocalAI, Azure OpenAI)
+func NewOpenAIClient() *openai.Client {
+	opts := []option.RequestOption{}
+	if config.OpenAIAPIKey != "" {
+		opts = append(opts, option.WithAPIKey(config.OpenAIAPIKey))
+	}
+	if config.OpenAIBaseURL != "" {
+		// Paths are resolved relative to the base URL, so it needs the slash
+		opts = append(opts, option.WithBaseURL(strings.TrimSuffix(config.OpenAIBaseURL, "/")+"/"))
+	}
+	if config.OpenAIOrganization != "" {
+		opts = append(opts, option.WithOrganization(config.OpenAIOrganization))
+	}
+	if config.OpenAIAPIVersion != "" {
+		opts = append(opts, option.WithQuery("api-version", config.OpenAIAPIVersion))
+	}
+	for _, header := range strings.Split(config.OpenAIHeaders, ",") {
+		key, value, found := strings.Cut(header, "=")
+		if found {
+			opts = append(opts, option.WithHeader(key, value))
+		}
+	}
+	return openai.NewClient(opts...)
+}
+
+// Returns the system message (if any), the few-shot examples and then the
+// message to evaluate
+func OpenAIMessages(m string) []openai.ChatCompletionMessageParamUnion {
+	messages := []openai.ChatCompletionMessageParamUnion{}
+	if config.OpenAISystemPrompt != "" {
+		messages = append(messages, openai.SystemMessage(config.OpenAISystemPrompt))
+	}
+	for _, example := range llmExamples {
+		messages = append(messages,
+			openai.UserMessage(OpenAIUserPrompt(example.Text)),
+			openai.AssistantMessage(example.Response()),
+		)
+	}
+	return append(messages, openai.UserMessage(OpenAIUserPrompt(m)))
+}
+
+func OpenAIUserPrompt(m string) string {
+	return fmt.Sprintf(OpenAIPromptTemplate, config.OpenAIPrompt, m)
+}
+
+// Asks OpenAI about a message, returning the decision and the outcome
+func OpenAIDecision(m string) (decision bool, outcome string) {
 	// If message is blank, return
-	if regexp.MustCompile(`^\s*$`).MatchString(m) {
+	if blankMessageRegex.MatchString(m) {
 		log.Info("message was blank, filtering without calling OpenAI")
-		return false
+		return false, FilterOutcomeBlank
 	}
-	client := openai.NewClient(
-		option.WithAPIKey(config.OpenAIAPIKey),
-	)
+	client := NewOpenAIClient()
 	if config.OpenAICustomPreamble != "" {
 		OpenAIPromptTemplate = config.OpenAICustomPreamble
 	}
-	openAIModel := openai.ChatModelGPT4o
-	if config.OpenAIModel != "" {
-		openAIModel = config.OpenAIModel
+	ctx := context.Background()
+	if config.OpenAITimeoutSeconds > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(config.OpenAITimeoutSeconds)*time.Second)
+		defer cancel()
+	}
+
+	params := openai.ChatCompletionNewParams{
+		Messages: openai.F(OpenAIMessages(m)),
+		Model:    openai.F(OpenAIModel()),
+	}
+	if format := OpenAIResponseFormat(); format != nil {
+		params.ResponseFormat = openai.F(format)
+	}
+
+	for attempt := 0; attempt <= LLMMalformedRetries(); attempt++ {
+		// The first call was charged by the limiter's wrapper
+		if attempt > 0 && !openAILimiter.Call() {
+			return false, FilterOutcomeLimited
+		}
+		log.Debugf("calling OpenAI, prompt: %s", config.OpenAIPrompt)
+		chatCompletion, err := client.Chat.Completions.New(ctx, params)
+		for OpenAIResponseFormatRejected(err) && FallBackOpenAIResponseFormat() {
+			if !openAILimiter.Call() {
+				return false, FilterOutcomeLimited
+			}
+			params.ResponseFormat = openai.Null[openai.ChatCompletionNewParamsResponseFormatUnion]()
+			if format := OpenAIResponseFormat(); format != nil {
+				params.ResponseFormat = openai.F(format)
+			}
+			chatCompletion, err = client.Chat.Completions.New(ctx, params)
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Errorf("timed out waiting for OpenAI: %s", err)
+			return false, FilterOutcomeTimeout
+		}
+		if err != nil {
+			log.Errorf("error using OpenAI: %s", err)
+			return false, FilterOutcomeError
+		}
+		openAILimiter.Record(chatCompletion.Usage.PromptTokens, chatCompletion.Usage.CompletionTokens)
+		if len(chatCompletion.Choices) == 0 {
+			log.Warn("OpenAI returned no choices")
+			continue
+		}
+		var r OpenAIResponse
+		content := chatCompletion.Choices[0].Message.Content
+		log.Debugf("response from OpenAI: %s", content)
+		err = ParseLLMJSON(content, &r)
+		if err != nil {
+			log.Warnf("error unmarshaling response from OpenAI (attempt %d): %s", attempt+1, err)
+			continue
+		}
+		return r.Decision, FilterOutcomeDecision
+	}
+	return false, FilterOutcomeUnparsable
+}
+
+// Response formats from strictest to loosest. When a server rejects one,
+// the next is used from then on.
+var OpenAIResponseFormats = []string{"json_schema", "json_object", "none"}
+
+var openAIResponseFormatFallback struct {
+	mu     sync.Mutex
+	format string
+}
+
+// The response format in use, which starts as the configured one
+func OpenAIResponseFormatName() string {
+	openAIResponseFormatFallback.mu.Lock()
+	defer openAIResponseFormatFallback.mu.Unlock()
+	if openAIResponseFormatFallback.format != "" {
+		return openAIResponseFormatFallback.format
+	}
+	if config.OpenAIResponseFormat == "" {
+		return OpenAIResponseFormats[0]
 	}
-	log.Debugf("calling OpenAI, prompt: %s", config.OpenAIPrompt)
-	chatCompletion, err := client.Chat.Completions.New(context.TODO(),
-		openai.ChatCompletionNewParams{
-			Messages: openai.F([]openai.ChatCompletionMessageParamUnion{
-				openai.UserMessage(fmt.Sprintf(OpenAIPromptTemplate, config.OpenAIPrompt, m)),
+	return strings.ToLower(config.OpenAIResponseFormat)
+}
+
+// Returns the response format to ask OpenAI for. Structured output is the
+// default, but not every OpenAI-compatible server supports it.
+func OpenAIResponseFormat() openai.ChatCompletionNewParamsResponseFormatUnion {
+	switch OpenAIResponseFormatName() {
+	case "json_schema":
+		return openai.ResponseFormatJSONSchemaParam{
+			Type: openai.F(openai.ResponseFormatJSONSchemaTypeJSONSchema),
+			JSONSchema: openai.F(openai.ResponseFormatJSONSchemaJSONSchemaParam{
+				Name:   openai.F("filter_decision"),
+				Schema: openai.F[any](LLMDecisionSchema),
+				Strict: openai.F(true),
 			}),
-			Model: openai.F(openAIModel),
-		})
-	if err != nil {
-		log.Errorf("error using OpenAI: %s", err)
-		return true
-	}
-	var r OpenAIResponse
-	content := chatCompletion.Choices[0].Message.Content
-	log.Debugf("response from OpenAI: %s", content)
-	err = json.Unmarshal([]byte(content), &r)
-	if err != nil {
-		log.Warnf("error unmarshaling response from OpenAI: %s", err)
-		return true
-	}
-	return r.Decision
+		}
+	case "json_object":
+		return openai.ResponseFormatJSONObjectParam{
+			Type: openai.F(openai.ResponseFormatJSONObjectTypeJSONObject),
+		}
+	default:
+		return nil
+	}
+}
+
+// Whether the server said it doesn't support the response format we asked
+// for, which OpenAI-compatible servers report as a 400 about response_format
+func OpenAIResponseFormatRejected(err error) bool {
+	var apiErr *openai.Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		return false
+	}
+	return apiErr.Param == "response_format" ||
+		strings.Contains(strings.ToLower(apiErr.Message+apiErr.JSON.RawJSON()), "response_format")
+}
+
+// Switches to the next looser response format, returning false if there
+// isn't one
+func FallBackOpenAIResponseFormat() bool {
+	current := OpenAIResponseFormatName()
+	i := slices.Index(OpenAIResponseFormats, current)
+	if i < 0 || i == len(OpenAIResponseFormats)-1 {
+		return false
+	}
+	next := OpenAIResponseFormats[i+1]
+	openAIResponseFormatFallback.mu.Lock()
+	openAIResponseFormatFallback.format = next
+	openAIResponseFormatFallback.mu.Unlock()
+	log.Warnf("OpenAI server rejected the %s response format, using %s instead", current, next)
+	return true
 }
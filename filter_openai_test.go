@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/openai/openai-go"
+)
+
+// An OpenAI-compatible server that only understands json_object
+func serveJSONObjectOnly(t *testing.T) (formats *[]string) {
+	formats = &[]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var request struct {
+			ResponseFormat struct {
+				Type string `json:"type"`
+			} `json:"response_format"`
+		}
+		json.Unmarshal(body, &request)
+		*formats = append(*formats, request.ResponseFormat.Type)
+		w.Header().Set("Content-Type", "application/json")
+		if request.ResponseFormat.Type == "json_schema" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error": {"message": "response_format json_schema is not supported", "type": "invalid_request_error", "param": "response_format", "code": null}}`))
+			return
+		}
+		w.Write([]byte(`{"id": "1", "object": "chat.completion", "created": 0, "model": "local", "choices": [{"index": 0, "finish_reason": "stop", "message": {"role": "assistant", "content": "{\"decision\": true, \"reasoning\": \"matches\"}"}}], "usage": {"prompt_tokens": 10, "completion_tokens": 5, "total_tokens": 15}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	previous := config
+	t.Cleanup(func() {
+		config = previous
+		openAIResponseFormatFallback.format = ""
+	})
+	config.OpenAIBaseURL = server.URL + "/v1"
+	config.OpenAIAPIKey = "test"
+	config.OpenAIModel = "local"
+	return formats
+}
+
+func TestOpenAIResponseFormatFallback(t *testing.T) {
+	formats := serveJSONObjectOnly(t)
+	decision, outcome := OpenAIDecision("REQUEST MEDICAL ASSISTANCE ON ARRIVAL")
+	if !decision || outcome != FilterOutcomeDecision {
+		t.Fatalf("got %t %q, want a decision of true", decision, outcome)
+	}
+	if name := OpenAIResponseFormatName(); name != "json_object" {
+		t.Errorf("response format is %q, want json_object", name)
+	}
+	// The next message goes straight to the format that works
+	OpenAIDecision("REQUEST MEDICAL ASSISTANCE ON ARRIVAL")
+	want := []string{"json_schema", "json_object", "json_object"}
+	if len(*formats) != len(want) {
+		t.Fatalf("server saw %v, want %v", *formats, want)
+	}
+	for i := range want {
+		if (*formats)[i] != want[i] {
+			t.Errorf("request %d asked for %q, want %q", i+1, (*formats)[i], want[i])
+		}
+	}
+}
+
+func TestFallBackOpenAIResponseFormat(t *testing.T) {
+	previous := config.OpenAIResponseFormat
+	t.Cleanup(func() {
+		config.OpenAIResponseFormat = previous
+		openAIResponseFormatFallback.format = ""
+	})
+	config.OpenAIResponseFormat = "JSON_Schema"
+	for _, want := range []string{"json_object", "none"} {
+		if !FallBackOpenAIResponseFormat() || OpenAIResponseFormatName() != want {
+			t.Errorf("got %q, want %q", OpenAIResponseFormatName(), want)
+		}
+	}
+	if FallBackOpenAIResponseFormat() {
+		t.Error("fell back past none")
+	}
+	if OpenAIResponseFormat() != nil {
+		t.Error("none should send no response format")
+	}
+}
+
+func TestOpenAICompatibleRequest(t *testing.T) {
+	var request *http.Request
+	var body struct {
+		Model    string `json:"model"`
+		Messages []struct {
+			Role string `json:"role"`
+			// A string or a list of text parts
+			Content json.RawMessage `json:"content"`
+		} `json:"messages"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request = r
+		contents, _ := io.ReadAll(r.Body)
+		json.Unmarshal(contents, &body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id": "1", "object": "chat.completion", "created": 0, "model": "local", "choices": [{"index": 0, "finish_reason": "stop", "message": {"role": "assistant", "content": "{\"decision\": false, \"reasoning\": \"automated\"}"}}]}`))
+	}))
+	t.Cleanup(server.Close)
+	previous, previousExamples := config, llmExamples
+	t.Cleanup(func() { config, llmExamples = previous, previousExamples })
+	llmExamples = []LLMExample{{Text: "/FB 0021/AD KSFO", Decision: false, Reasoning: "automated position report"}}
+	config.OpenAIBaseURL = server.URL + "/openai/v1/"
+	config.OpenAIAPIKey = "test"
+	config.OpenAIModel = ""
+	config.OpenAIAPIVersion = "2024-06-01"
+	config.OpenAIHeaders = "X-Team=acars,malformed"
+	config.OpenAISystemPrompt = "You sort ACARS messages."
+
+	decision, outcome := OpenAIDecision("/FB 0022/AD KDEN")
+	if decision || outcome != FilterOutcomeDecision {
+		t.Fatalf("got %t %q, want a decision of false", decision, outcome)
+	}
+	if request.URL.Path != "/openai/v1/chat/completions" || request.URL.Query().Get("api-version") != "2024-06-01" {
+		t.Errorf("requested %s", request.URL)
+	}
+	if request.Header.Get("X-Team") != "acars" || request.Header.Get("Authorization") != "Bearer test" {
+		t.Errorf("got headers %v", request.Header)
+	}
+	if body.Model != openai.ChatModelGPT4o {
+		t.Errorf("got model %q, want the default", body.Model)
+	}
+	roles := []string{}
+	for _, message := range body.Messages {
+		roles = append(roles, message.Role)
+	}
+	if len(roles) != 4 || roles[0] != "system" || roles[1] != "user" || roles[2] != "assistant" || roles[3] != "user" {
+		t.Errorf("got roles %v, want the system prompt, an example and the message", roles)
+	}
+	if len(body.Messages) > 0 && !strings.Contains(string(body.Messages[0].Content), config.OpenAISystemPrompt) {
+		t.Errorf("got system prompt %s", body.Messages[0].Content)
+	}
+}
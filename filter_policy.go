@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// What an external filter does when it couldn't come to a decision
+const (
+	FilterActionPass     = "pass"
+	FilterActionDrop     = "drop"
+	FilterActionFallback = "fallback"
+)
+
+// Everything that can happen when asking an external filter about a message
+const (
+	FilterOutcomeDecision   = "decision"
+	FilterOutcomeError      = "error"
+	FilterOutcomeTimeout    = "timeout"
+	FilterOutcomeBlank      = "blank"
+	FilterOutcomeUnparsable = "unparsable"
+	FilterOutcomeLimited    = "limited"
+)
+
+var blankMessageRegex = regexp.MustCompile(`^\s*$`)
+
+// Decides what an external (OpenAI, Ollama) filter returns when it
+// errors, times out, gets a blank message or can't parse the response
+type FilterPolicy struct {
+	Name         string
+	OnError      string
+	OnTimeout    string
+	OnBlank      string
+	OnUnparsable string
+	OnLimited    string
+	Fallback     string
+}
+
+// A filter that takes message text and returns its decision along with
+// the outcome that led to it
+type TextFilterFunction func(m string) (decision bool, outcome string)
+
+// Filters that an external filter may defer to when it has no decision.
+// These never fall back themselves, so there are no loops.
+var FallbackFilterFunctions = map[string]TextFilterFunction{
+	"OpenAIPromptFilter": func(m string) (bool, string) {
+		return openAILimiter.Wrap(OpenAIDecision)(m)
+	},
+	"OllamaPromptFilter": func(m string) (bool, string) {
+		return ollamaLimiter.Wrap(OllamaDecision)(m)
+	},
+	"HasText": func(m string) (bool, string) {
+		return !blankMessageRegex.MatchString(m), FilterOutcomeDecision
+	},
+	"ConsecutiveDictionaryWordCount": func(m string) (bool, string) {
+		return config.FilterCriteriaDictionaryPhraseLengthMinimum <= LongestDictionaryWordPhraseLength(m), FilterOutcomeDecision
+	},
+}
+
+// Returns the policy action for an outcome, or def if none was configured
+func policyAction(configured, def string) string {
+	configured = strings.ToLower(strings.TrimSpace(configured))
+	switch configured {
+	case FilterActionPass, FilterActionDrop, FilterActionFallback:
+		return configured
+	case "":
+		return def
+	default:
+		log.Warnf("unknown filter policy action %q, using %q", configured, def)
+		return def
+	}
+}
+
+// Returns the action to take for a given outcome
+func (p FilterPolicy) Action(outcome string) string {
+	switch outcome {
+	case FilterOutcomeError:
+		return p.OnError
+	case FilterOutcomeTimeout:
+		return p.OnTimeout
+	case FilterOutcomeBlank:
+		return p.OnBlank
+	case FilterOutcomeUnparsable:
+		return p.OnUnparsable
+	case FilterOutcomeLimited:
+		return p.OnLimited
+	}
+	return FilterActionPass
+}
+
+// Runs the filter and applies the policy to whatever happened
+func (p FilterPolicy) Evaluate(m string, filter TextFilterFunction) bool {
+	decision, outcome := filter(m)
+	metrics.Increment(fmt.Sprintf("filter.%s.%s", p.Name, outcome))
+	if outcome == FilterOutcomeDecision {
+		return decision
+	}
+
+	action := p.Action(outcome)
+	log.Infof("%s filter outcome was %s, policy is to %s", p.Name, outcome, action)
+	switch action {
+	case FilterActionDrop:
+		return false
+	case FilterActionFallback:
+		return p.fallback(m)
+	default:
+		return true
+	}
+}
+
+// Asks the fallback filter, passing the message if it has no decision either
+func (p FilterPolicy) fallback(m string) bool {
+	fallback, ok := FallbackFilterFunctions[p.Fallback]
+	if !ok {
+		log.Warnf("%s filter fallback %q is not a known filter, passing message", p.Name, p.Fallback)
+		metrics.Increment(fmt.Sprintf("filter.%s.fallback_unavailable", p.Name))
+		return true
+	}
+	decision, outcome := fallback(m)
+	metrics.Increment(fmt.Sprintf("filter.%s.fallback_%s", p.Name, outcome))
+	if outcome != FilterOutcomeDecision {
+		log.Warnf("%s filter fallback %s outcome was %s, passing message", p.Name, p.Fallback, outcome)
+		return true
+	}
+	return decision
+}
+
+func OpenAIFilterPolicy() FilterPolicy {
+	return FilterPolicy{
+		Name:         "openai",
+		OnError:      policyAction(config.OpenAIOnError, FilterActionPass),
+		OnTimeout:    policyAction(config.OpenAIOnTimeout, FilterActionPass),
+		OnBlank:      policyAction(config.OpenAIOnBlank, FilterActionDrop),
+		OnUnparsable: policyAction(config.OpenAIOnUnparsable, FilterActionPass),
+		OnLimited:    policyAction(config.OpenAIOnLimit, FilterActionPass),
+		Fallback:     config.OpenAIFallbackFilter,
+	}
+}
+
+func OllamaFilterPolicy() FilterPolicy {
+	return FilterPolicy{
+		Name:         "ollama",
+		OnError:      policyAction(config.OllamaOnError, FilterActionPass),
+		OnTimeout:    policyAction(config.OllamaOnTimeout, FilterActionPass),
+		OnBlank:      policyAction(config.OllamaOnBlank, FilterActionPass),
+		OnUnparsable: policyAction(config.OllamaOnUnparsable, FilterActionPass),
+		OnLimited:    policyAction(config.OllamaOnLimit, FilterActionPass),
+		Fallback:     config.OllamaFallbackFilter,
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import "testing"
+
+func TestPolicyAction(t *testing.T) {
+	tests := []struct{ configured, want string }{
+		{"", FilterActionPass},
+		{" Drop ", FilterActionDrop},
+		{"fallback", FilterActionFallback},
+		{"explode", FilterActionPass},
+	}
+	for _, test := range tests {
+		if got := policyAction(test.configured, FilterActionPass); got != test.want {
+			t.Errorf("%q: got %q, want %q", test.configured, got, test.want)
+		}
+	}
+}
+
+func TestFilterPolicyEvaluate(t *testing.T) {
+	policy := FilterPolicy{
+		Name:         "test",
+		OnError:      FilterActionDrop,
+		OnTimeout:    FilterActionPass,
+		OnBlank:      FilterActionDrop,
+		OnUnparsable: FilterActionFallback,
+		OnLimited:    FilterActionFallback,
+		Fallback:     "HasText",
+	}
+	tests := []struct {
+		name     string
+		policy   FilterPolicy
+		message  string
+		decision bool
+		outcome  string
+		want     bool
+	}{
+		{"decision kept", policy, "hello", false, FilterOutcomeDecision, false},
+		{"error dropped", policy, "hello", true, FilterOutcomeError, false},
+		{"timeout passed", policy, "hello", false, FilterOutcomeTimeout, true},
+		{"blank dropped", policy, " ", true, FilterOutcomeBlank, false},
+		{"unparsable falls back to text", policy, "hello", false, FilterOutcomeUnparsable, true},
+		{"limited falls back to no text", policy, " ", true, FilterOutcomeLimited, false},
+		{"unknown fallback passes", FilterPolicy{Name: "test", OnError: FilterActionFallback, Fallback: "Nope"}, " ", false, FilterOutcomeError, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := test.policy.Evaluate(test.message, func(string) (bool, string) {
+				return test.decision, test.outcome
+			})
+			if got != test.want {
+				t.Errorf("got %t, want %t", got, test.want)
+			}
+		})
+	}
+}
+
+func TestFilterPolicyDefaults(t *testing.T) {
+	previous := config
+	t.Cleanup(func() { config = previous })
+	config = Config{}
+	// OpenAI drops blank messages by default, Ollama passes everything
+	if got := OpenAIFilterPolicy().Action(FilterOutcomeBlank); got != FilterActionDrop {
+		t.Errorf("openai blank: got %q", got)
+	}
+	if got := OllamaFilterPolicy().Action(FilterOutcomeBlank); got != FilterActionPass {
+		t.Errorf("ollama blank: got %q", got)
+	}
+	config.OllamaOnTimeout = "drop"
+	if got := OllamaFilterPolicy().Action(FilterOutcomeTimeout); got != FilterActionDrop {
+		t.Errorf("ollama timeout: got %q", got)
+	}
+}
@@ -66,7 +66,8 @@ var (
 		},
 		"Emergency": func(tar Tar1090AircraftJSON) (result bool) {
 			for _, aircraft := range tar.Aircraft {
-				if aircraft.Emergency != "" {
+				// readsb sends "none" when there isn't one
+				if aircraft.Emergency != "" && aircraft.Emergency != "none" {
 					result = true
 					break
 				}
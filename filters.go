@@ -1,6 +1,7 @@
 package main
 
 import (
+	"slices"
 	"strings"
 
 	log "github.com/sirupsen/logrus"
@@ -49,9 +50,19 @@ func ConfigureFilters() {
 	if config.FilterCriteriaDictionaryPhraseLengthMinimum > 0 {
 		enabledFilters = append(enabledFilters, "ConsecutiveDictionaryWordCount")
 	}
-	if config.OpenAIAPIKey != "" {
+	if config.OpenAIAPIKey != "" || config.OpenAIBaseURL != "" {
+		if !slices.Contains(OpenAIResponseFormats, OpenAIResponseFormatName()) {
+			log.Fatalf("unknown FILTER_OPENAI_RESPONSE_FORMAT %q, expected one of %s",
+				config.OpenAIResponseFormat, strings.Join(OpenAIResponseFormats, ", "))
+		}
 		enabledFilters = append(enabledFilters, "OpenAIPromptFilter")
 	}
+	if config.OllamaURL != "" {
+		if !slices.Contains([]string{"", "json", "schema", "none"}, strings.ToLower(config.OllamaFormat)) {
+			log.Fatalf("unknown FILTER_OLLAMA_FORMAT %q, expected json, schema or none", config.OllamaFormat)
+		}
+		enabledFilters = append(enabledFilters, "OllamaPromptFilter")
+	}
 	log.Infof("enabled filters: %s", strings.Join(enabledFilters, ","))
 }
 
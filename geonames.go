@@ -0,0 +1,342 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/jftuga/geodist"
+	log "github.com/sirupsen/logrus"
+)
+
+type City struct {
+	Name        string
+	CountryCode string
+	Admin1Code  string
+	Population  int64
+	Latitude    float64
+	Longitude   float64
+}
+
+// A country or state/province outline
+type Boundary struct {
+	Name        string
+	Code        string
+	CountryCode string
+	// Each polygon is an outer ring followed by any holes, as [lon, lat]
+	// pairs like GeoJSON
+	polygons [][][][2]float64
+	// Bounding box, checked before the polygons
+	minLat, maxLat, minLon, maxLon float64
+}
+
+// Cities from GeoNames, bucketed into one degree cells so finding the
+// nearest doesn't scan the whole world, plus the names and outlines needed
+// to describe a position
+type LocationDatabase struct {
+	cells           map[[2]int][]City
+	admin1          map[string]string
+	countries       map[string]string
+	boundaries      []Boundary
+	adminBoundaries []Boundary
+}
+
+var locationDB *LocationDatabase
+
+const (
+	// How many cells out from the aircraft to look for a city, about 550 km
+	// at the equator
+	locationMaxCellRings = 5
+	// Closer than this and the aircraft is over the city
+	locationOverCityKm = 2.0
+)
+
+func ConfigureLocationDatabase() {
+	if !config.AnnotateLocation {
+		return
+	}
+	if config.GeoNamesCitiesFile == "" {
+		log.Fatal("location annotator needs GEONAMES_CITIES_FILE")
+	}
+	db, err := LoadLocationDatabase(config.GeoNamesCitiesFile, config.LocationMinPopulation)
+	if err != nil {
+		log.Fatalf("error loading cities: %s", err)
+	}
+	if config.GeoNamesAdmin1File != "" {
+		if db.admin1, err = LoadGeoNamesNames(config.GeoNamesAdmin1File, 1); err != nil {
+			log.Fatalf("error loading admin1 codes: %s", err)
+		}
+	}
+	if config.GeoNamesCountryFile != "" {
+		if db.countries, err = LoadGeoNamesNames(config.GeoNamesCountryFile, 4); err != nil {
+			log.Fatalf("error loading countries: %s", err)
+		}
+	}
+	if config.LocationCountryBoundariesFile != "" {
+		if db.boundaries, err = LoadBoundaries(config.LocationCountryBoundariesFile); err != nil {
+			log.Fatalf("error loading country boundaries: %s", err)
+		}
+	}
+	if config.LocationAdminBoundariesFile != "" {
+		if db.adminBoundaries, err = LoadBoundaries(config.LocationAdminBoundariesFile); err != nil {
+			log.Fatalf("error loading admin boundaries: %s", err)
+		}
+	}
+	log.Infof("loaded %d cities from %s", db.CityCount(), config.GeoNamesCitiesFile)
+	locationDB = db
+}
+
+func locationCell(lat, lon float64) [2]int {
+	return [2]int{int(math.Floor(lat)), int(math.Floor(lon))}
+}
+
+// Reads a GeoNames cities file, ex: cities15000.txt. Columns are tab
+// separated and documented at https://download.geonames.org/export/dump/
+func LoadLocationDatabase(path string, minPopulation int64) (*LocationDatabase, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	db := &LocationDatabase{cells: map[[2]int][]City{}}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		row := strings.Split(scanner.Text(), "\t")
+		if len(row) < 15 {
+			continue
+		}
+		lat, latErr := strconv.ParseFloat(row[4], 64)
+		lon, lonErr := strconv.ParseFloat(row[5], 64)
+		if latErr != nil || lonErr != nil {
+			continue
+		}
+		population, _ := strconv.ParseInt(row[14], 10, 64)
+		if population < minPopulation {
+			continue
+		}
+		city := City{
+			Name:        row[1],
+			CountryCode: row[8],
+			Admin1Code:  row[10],
+			Population:  population,
+			Latitude:    lat,
+			Longitude:   lon,
+		}
+		cell := locationCell(lat, lon)
+		db.cells[cell] = append(db.cells[cell], city)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(db.cells) == 0 {
+		return nil, errors.New("no cities found in " + path)
+	}
+	return db, nil
+}
+
+// Reads a GeoNames code to name file, ex: admin1CodesASCII.txt ("US.CO",
+// "Colorado", ...) or countryInfo.txt ("US", ..., "United States", ...)
+func LoadGeoNamesNames(path string, nameColumn int) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	names := map[string]string{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		row := strings.Split(line, "\t")
+		if len(row) > nameColumn && row[0] != "" {
+			names[row[0]] = row[nameColumn]
+		}
+	}
+	return names, scanner.Err()
+}
+
+// Reads a GeoJSON FeatureCollection of Polygons and MultiPolygons, ex:
+// Natural Earth's admin 0 countries or admin 1 states and provinces
+func LoadBoundaries(path string) ([]Boundary, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var collection struct {
+		Features []struct {
+			Properties map[string]any `json:"properties"`
+			Geometry   struct {
+				Type        string          `json:"type"`
+				Coordinates json.RawMessage `json:"coordinates"`
+			} `json:"geometry"`
+		} `json:"features"`
+	}
+	if err := json.Unmarshal(contents, &collection); err != nil {
+		return nil, err
+	}
+	property := func(properties map[string]any, keys ...string) string {
+		for _, key := range keys {
+			if value, ok := properties[key].(string); ok && value != "" && value != "-99" {
+				return value
+			}
+		}
+		return ""
+	}
+	boundaries := []Boundary{}
+	for _, feature := range collection.Features {
+		boundary := Boundary{
+			Name:        property(feature.Properties, "name", "NAME", "ADMIN", "admin"),
+			Code:        property(feature.Properties, "postal", "POSTAL", "iso_a2", "ISO_A2"),
+			CountryCode: property(feature.Properties, "iso_a2", "ISO_A2", "ISO_A2_EH"),
+		}
+		switch feature.Geometry.Type {
+		case "Polygon":
+			var polygon [][][2]float64
+			if err := json.Unmarshal(feature.Geometry.Coordinates, &polygon); err != nil {
+				return nil, err
+			}
+			boundary.polygons = [][][][2]float64{polygon}
+		case "MultiPolygon":
+			if err := json.Unmarshal(feature.Geometry.Coordinates, &boundary.polygons); err != nil {
+				return nil, err
+			}
+		default:
+			continue
+		}
+		boundary.minLat, boundary.minLon = math.Inf(1), math.Inf(1)
+		boundary.maxLat, boundary.maxLon = math.Inf(-1), math.Inf(-1)
+		for _, polygon := range boundary.polygons {
+			if len(polygon) == 0 {
+				continue
+			}
+			for _, point := range polygon[0] {
+				boundary.minLon, boundary.maxLon = math.Min(boundary.minLon, point[0]), math.Max(boundary.maxLon, point[0])
+				boundary.minLat, boundary.maxLat = math.Min(boundary.minLat, point[1]), math.Max(boundary.maxLat, point[1])
+			}
+		}
+		boundaries = append(boundaries, boundary)
+	}
+	return boundaries, nil
+}
+
+// Even-odd ray casting, which is fine for the small areas a boundary covers
+func ringContains(ring [][2]float64, lat, lon float64) bool {
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		xi, yi := ring[i][0], ring[i][1]
+		xj, yj := ring[j][0], ring[j][1]
+		if (yi > lat) != (yj > lat) && lon < (xj-xi)*(lat-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+func (b Boundary) Contains(lat, lon float64) bool {
+	if lat < b.minLat || lat > b.maxLat || lon < b.minLon || lon > b.maxLon {
+		return false
+	}
+	for _, polygon := range b.polygons {
+		if len(polygon) == 0 || !ringContains(polygon[0], lat, lon) {
+			continue
+		}
+		inHole := false
+		for _, hole := range polygon[1:] {
+			if ringContains(hole, lat, lon) {
+				inHole = true
+				break
+			}
+		}
+		if !inHole {
+			return true
+		}
+	}
+	return false
+}
+
+func findBoundary(boundaries []Boundary, lat, lon float64) (Boundary, bool) {
+	for _, boundary := range boundaries {
+		if boundary.Contains(lat, lon) {
+			return boundary, true
+		}
+	}
+	return Boundary{}, false
+}
+
+func (db *LocationDatabase) CityCount() (count int) {
+	for _, cities := range db.cells {
+		count += len(cities)
+	}
+	return count
+}
+
+// Finds the closest city, searching outward a ring of cells at a time and
+// stopping one ring after the first match since a city in the next ring can
+// still be closer
+func (db *LocationDatabase) NearestCity(lat, lon float64) (city City, km float64, found bool) {
+	if db == nil {
+		return city, 0, false
+	}
+	position := geodist.Coord{Lat: lat, Lon: lon}
+	center := locationCell(lat, lon)
+	lastRing := locationMaxCellRings
+	for ring := 0; ring <= lastRing; ring++ {
+		for dLat := -ring; dLat <= ring; dLat++ {
+			for dLon := -ring; dLon <= ring; dLon++ {
+				if max(abs(dLat), abs(dLon)) != ring {
+					continue
+				}
+				// Wrap across the antimeridian
+				cellLon := (center[1]+dLon+180+360)%360 - 180
+				for _, candidate := range db.cells[[2]int{center[0] + dLat, cellLon}] {
+					_, distance := geodist.HaversineDistance(position, geodist.Coord{Lat: candidate.Latitude, Lon: candidate.Longitude})
+					if !found || distance < km {
+						city, km, found = candidate, distance, true
+					}
+				}
+			}
+		}
+		if found && lastRing > ring+1 {
+			lastRing = ring + 1
+		}
+	}
+	return city, km, found
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// ex: "CO" for Colorado, whose GeoNames admin1 code is already short, or
+// the name for countries that use numbered codes
+func (db *LocationDatabase) AdminName(city City) string {
+	if city.Admin1Code == "" || city.Admin1Code == "00" {
+		return ""
+	}
+	if _, err := strconv.Atoi(city.Admin1Code); err != nil && len(city.Admin1Code) <= 3 {
+		return city.Admin1Code
+	}
+	return db.admin1[city.CountryCode+"."+city.Admin1Code]
+}
+
+// The country the position is over, if boundaries are loaded
+func (db *LocationDatabase) Country(lat, lon float64) (Boundary, bool) {
+	return findBoundary(db.boundaries, lat, lon)
+}
+
+// The state or province the position is over, if boundaries are loaded
+func (db *LocationDatabase) Admin(lat, lon float64) (Boundary, bool) {
+	return findBoundary(db.adminBoundaries, lat, lon)
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTestFile(t *testing.T, name, contents string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// A cities15000.txt row with only the columns the loader reads filled in
+func geoNamesCityRow(name, lat, lon, country, admin1, population string) string {
+	row := make([]string, 19)
+	row[1], row[4], row[5], row[8], row[10], row[14] = name, lat, lon, country, admin1, population
+	return strings.Join(row, "\t")
+}
+
+func testLocationDatabase(t *testing.T) *LocationDatabase {
+	cities := writeTestFile(t, "cities.txt", strings.Join([]string{
+		geoNamesCityRow("Denver", "39.7392", "-104.9903", "US", "CO", "715522"),
+		geoNamesCityRow("Boulder", "40.015", "-105.27", "US", "CO", "108250"),
+		geoNamesCityRow("Tiny", "39.9", "-104.99", "US", "CO", "10"),
+		geoNamesCityRow("Suva", "-18.1416", "178.4419", "FJ", "01", "93970"),
+		geoNamesCityRow("Taveuni", "-16.8", "179.98", "FJ", "03", "20000"),
+	}, "\n"))
+	db, err := LoadLocationDatabase(cities, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestLoadGeoNamesNames(t *testing.T) {
+	path := writeTestFile(t, "countryInfo.txt", strings.Join([]string{
+		"#ISO\tISO3\tISO-Numeric\tfips\tCountry",
+		"US\tUSA\t840\tUS\tUnited States",
+		"FJ\tFJI\t242\tFJ\tFiji",
+		"XX\tshort",
+	}, "\n"))
+	names, err := LoadGeoNamesNames(path, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 2 || names["US"] != "United States" || names["FJ"] != "Fiji" {
+		t.Errorf("got %v", names)
+	}
+	if _, err := LoadGeoNamesNames(filepath.Join(t.TempDir(), "missing.txt"), 1); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
+
+func TestNearestCity(t *testing.T) {
+	db := testLocationDatabase(t)
+	if count := db.CityCount(); count != 4 {
+		t.Errorf("loaded %d cities, want 4 above the minimum population", count)
+	}
+	tests := []struct {
+		name     string
+		lat, lon float64
+		want     string
+		found    bool
+	}{
+		{"over the city", 39.74, -104.99, "Denver", true},
+		// Closer to Tiny, which is below the minimum population
+		{"north of denver", 39.9, -104.99, "Denver", true},
+		{"closer to boulder", 40.0, -105.2, "Boulder", true},
+		{"across the antimeridian", -16.8, -179.98, "Taveuni", true},
+		{"too far from anything", 0, 0, "", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			city, _, found := db.NearestCity(test.lat, test.lon)
+			if found != test.found || city.Name != test.want {
+				t.Errorf("got %q %t, want %q %t", city.Name, found, test.want, test.found)
+			}
+		})
+	}
+	var none *LocationDatabase
+	if _, _, found := none.NearestCity(0, 0); found {
+		t.Error("a nil database found a city")
+	}
+}
+
+func TestBoundaryContains(t *testing.T) {
+	boundaries, err := LoadBoundaries(writeTestFile(t, "boundaries.geojson", `{"type": "FeatureCollection", "features": [
+		{"properties": {"NAME": "Square", "ISO_A2": "-99", "ISO_A2_EH": "SQ", "POSTAL": "SQ"},
+		 "geometry": {"type": "Polygon", "coordinates": [
+			[[0, 0], [10, 0], [10, 10], [0, 10], [0, 0]],
+			[[4, 4], [6, 4], [6, 6], [4, 6], [4, 4]]
+		 ]}},
+		{"properties": {"name": "Islands", "iso_a2": "IS"},
+		 "geometry": {"type": "MultiPolygon", "coordinates": [
+			[[[20, 0], [21, 0], [21, 1], [20, 1], [20, 0]]],
+			[[[30, 0], [31, 0], [31, 1], [30, 1], [30, 0]]]
+		 ]}},
+		{"properties": {"name": "A point"}, "geometry": {"type": "Point", "coordinates": [1, 1]}}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(boundaries) != 2 {
+		t.Fatalf("got %d boundaries, want 2", len(boundaries))
+	}
+	if square := boundaries[0]; square.Name != "Square" || square.CountryCode != "SQ" || square.Code != "SQ" {
+		t.Errorf("got %+v", square)
+	}
+
+	tests := []struct {
+		name     string
+		lat, lon float64
+		want     string
+	}{
+		{"inside", 2, 2, "Square"},
+		{"in the hole", 5, 5, ""},
+		{"outside the bounding box", 20, 5, ""},
+		{"first island", 0.5, 20.5, "Islands"},
+		{"second island", 0.5, 30.5, "Islands"},
+		{"between the islands", 0.5, 25, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			boundary, _ := findBoundary(boundaries, test.lat, test.lon)
+			if boundary.Name != test.want {
+				t.Errorf("got %q, want %q", boundary.Name, test.want)
+			}
+		})
+	}
+}
+
+func TestRingContains(t *testing.T) {
+	// A concave "L"
+	ring := [][2]float64{{0, 0}, {2, 0}, {2, 1}, {1, 1}, {1, 2}, {0, 2}}
+	tests := []struct {
+		lat, lon float64
+		want     bool
+	}{
+		{0.5, 0.5, true},
+		{0.5, 1.5, true},
+		{1.5, 0.5, true},
+		{1.5, 1.5, false},
+		{-0.5, 0.5, false},
+	}
+	for _, test := range tests {
+		if got := ringContains(ring, test.lat, test.lon); got != test.want {
+			t.Errorf("%f,%f: got %t, want %t", test.lat, test.lon, got, test.want)
+		}
+	}
+}
+
+func TestDescribeLocation(t *testing.T) {
+	previous := locationDB
+	t.Cleanup(func() { locationDB = previous })
+	locationDB = testLocationDatabase(t)
+	locationDB.countries = map[string]string{"US": "United States"}
+
+	annotation := DescribeLocation(39.9, -104.99)
+	if got, want := annotation["locationDescription"], "10 nm N of Denver, CO, US"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if annotation["locationCountry"] != "United States" || annotation["locationCountryCode"] != "US" {
+		t.Errorf("got country %v %v", annotation["locationCountry"], annotation["locationCountryCode"])
+	}
+	if _, ok := annotation["locationOverWater"]; ok {
+		t.Error("over water without boundaries loaded")
+	}
+	if got := DescribeLocation(39.74, -104.99)["locationDescription"]; got != "over Denver, CO, US" {
+		t.Errorf("got %q over the city", got)
+	}
+
+	// With boundaries, the country comes from what the aircraft is over
+	locationDB.boundaries = []Boundary{{
+		Name:        "Somewhere",
+		CountryCode: "SW",
+		polygons:    [][][][2]float64{{{{0, 0}, {10, 0}, {10, 10}, {0, 10}}}},
+		maxLat:      10,
+		maxLon:      10,
+	}}
+	annotation = DescribeLocation(39.9, -104.99)
+	if annotation["locationOverWater"] != true || annotation["locationCountry"] != nil {
+		t.Errorf("got %v", annotation)
+	}
+	annotation = DescribeLocation(5, 5)
+	if annotation["locationDescription"] != "over Somewhere" || annotation["locationCountryCode"] != "SW" {
+		t.Errorf("got %v", annotation)
+	}
+}
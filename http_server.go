@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Serves metrics, and reception stats when they're enabled, on
+// HTTP_ADDRESS. RECEPTION_HTTP_ADDRESS is still honored for existing setups.
+func ConfigureHTTPServer() {
+	address := config.HTTPAddress
+	if address == "" {
+		address = config.ReceptionHTTPAddress
+	}
+	if address == "" {
+		return
+	}
+	go ServeHTTP(address)
+}
+
+func ServeHTTP(address string) {
+	log.Infof("serving metrics on %s", address)
+	if err := http.ListenAndServe(address, NewHTTPMux()); err != nil {
+		log.Errorf("error serving metrics: %s", err)
+	}
+}
+
+func NewHTTPMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics.json", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, "application/json", metrics.Snapshot())
+	})
+	if receptionStats != nil {
+		HandleReceptionStats(mux)
+	}
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, contentType string, body any) {
+	contents, err := json.Marshal(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	_, _ = w.Write(contents)
+}
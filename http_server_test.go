@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHTTPServer(t *testing.T) {
+	previousMetrics, previousStats := metrics, receptionStats
+	t.Cleanup(func() { metrics, receptionStats = previousMetrics, previousStats })
+	metrics = &Metrics{counters: map[string]int64{}}
+	metrics.Increment("llmcache.openai.hit")
+	metrics.Add("llm.openai.tokens.prompt", 120)
+
+	// Metrics are served without reception stats
+	receptionStats = nil
+	server := httptest.NewServer(NewHTTPMux())
+	defer server.Close()
+	response, err := http.Get(server.URL + "/metrics.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	var got map[string]int64
+	if err := json.NewDecoder(response.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got["llmcache.openai.hit"] != 1 || got["llm.openai.tokens.prompt"] != 120 {
+		t.Errorf("got %v", got)
+	}
+	response, err = http.Get(server.URL + "/reception.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusNotFound {
+		t.Errorf("reception.json without reception stats: got status %d", response.StatusCode)
+	}
+
+	receptionStats = NewReceptionStats(10, 10, 0)
+	reception := httptest.NewServer(NewHTTPMux())
+	defer reception.Close()
+	for _, path := range []string{"/metrics.json", "/reception.json", "/reception.geojson"} {
+		response, err := http.Get(reception.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response.Body.Close()
+		if response.StatusCode != http.StatusOK {
+			t.Errorf("%s: got status %d", path, response.StatusCode)
+		}
+	}
+}
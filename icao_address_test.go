@@ -0,0 +1,88 @@
+package main
+
+import "testing"
+
+func TestICAOAddressCountry(t *testing.T) {
+	tests := []struct {
+		hex      string
+		want     string
+		found    bool
+		military bool
+	}{
+		{"A1B2C3", "United States", true, false},
+		{"~a1b2c3", "United States", true, false},
+		{"AE1234", "United States", true, true},
+		// Hong Kong's block sits inside China's
+		{"789123", "Hong Kong", true, false},
+		{"780123", "China", true, false},
+		{"C01234", "Canada", true, false},
+		{"C21234", "Canada", true, true},
+		{"490123", "Portugal", true, false},
+		{"FFFFFF", "", false, false},
+	}
+	for _, test := range tests {
+		address, ok := ParseICAOAddress(test.hex)
+		if !ok {
+			t.Fatalf("%s didn't parse", test.hex)
+		}
+		country, found := ICAOAddressCountry(address)
+		if country != test.want || found != test.found || ICAOAddressMilitary(address) != test.military {
+			t.Errorf("%s: got %q %t military %t", test.hex, country, found, ICAOAddressMilitary(address))
+		}
+	}
+	for _, hex := range []string{"", "A1B2C", "A1B2C3D", "G1B2C3"} {
+		if _, ok := ParseICAOAddress(hex); ok {
+			t.Errorf("%q parsed", hex)
+		}
+	}
+}
+
+func TestRegistrationMatchesCountry(t *testing.T) {
+	tests := []struct {
+		registration, country string
+		matches, ok           bool
+	}{
+		{".N12345", "United States", true, true},
+		{"C-FABC", "Canada", true, true},
+		// Portugal's mark starts with Canada's
+		{"CS-TJE", "Canada", false, true},
+		{"CS-TJE", "Portugal", true, true},
+		{"CC-BAA", "Canada", false, true},
+		{"B-HKA", "Hong Kong", true, true},
+		{"B-1234", "China", true, true},
+		{"G-EUPA", "France", false, true},
+		{"", "United States", false, false},
+		{"N12345", "Zimbabwe", false, false},
+	}
+	for _, test := range tests {
+		matches, ok := RegistrationMatchesCountry(test.registration, test.country)
+		if matches != test.matches || ok != test.ok {
+			t.Errorf("%q %s: got %t %t, want %t %t", test.registration, test.country, matches, ok, test.matches, test.ok)
+		}
+	}
+}
+
+func TestICAOAddressAnnotatorMismatch(t *testing.T) {
+	var downlink VDLM2Message
+	downlink.VDL2.AVLC.Source.Type = "Aircraft"
+	downlink.VDL2.AVLC.Source.Address = "A1B2C3"
+	downlink.VDL2.AVLC.ACARS.Registration = ".G-EUPA"
+	if got := (ICAOAddressAnnotator{}).AnnotateVDLM2Message(downlink); got["icaoAddressRegistrationMismatch"] != true {
+		t.Errorf("downlink got %v", got)
+	}
+
+	// On an uplink the address is where the ground sent it, so it isn't
+	// checked against the registration
+	var uplink VDLM2Message
+	uplink.VDL2.AVLC.Source.Type = "Ground station"
+	uplink.VDL2.AVLC.Destination.Type = "Aircraft"
+	uplink.VDL2.AVLC.Destination.Address = "A1B2C3"
+	uplink.VDL2.AVLC.ACARS.Registration = ".G-EUPA"
+	got := (ICAOAddressAnnotator{}).AnnotateVDLM2Message(uplink)
+	if got["icaoAddressCountry"] != "United States" {
+		t.Errorf("uplink got %v", got)
+	}
+	if _, checked := got["icaoAddressRegistrationMismatch"]; checked {
+		t.Errorf("uplink registration was checked: %v", got)
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultSBSPort        = 30003
+	defaultReadsbJSONPort = 30047
+	streamReconnectDelay  = 10 * time.Second
+)
+
+// Connects to a line based stream and hands each line to handle, reconnecting
+// if the connection drops
+func ReadLineStream(name, address string, handle func(line string)) {
+	for {
+		log.Debugf("connecting to %s at %s", name, address)
+		conn, err := net.Dial("tcp", address)
+		if err != nil {
+			log.Errorf("error connecting to %s: %v", name, err)
+			time.Sleep(streamReconnectDelay)
+			continue
+		}
+		log.Infof("connected to %s successfully", name)
+		scanner := bufio.NewScanner(conn)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			handle(scanner.Text())
+		}
+		log.Warnf("lost connection to %s: %v", name, scanner.Err())
+		conn.Close()
+		time.Sleep(streamReconnectDelay)
+	}
+}
+
+func ReadSBSStream() {
+	port := config.SBSPort
+	if port == 0 {
+		port = defaultSBSPort
+	}
+	ReadLineStream("sbs", net.JoinHostPort(config.SBSHost, strconv.Itoa(port)), func(line string) {
+		HandleSBSLine(aircraftState, line)
+	})
+}
+
+func ReadReadsbJSONStream() {
+	port := config.ReadsbJSONPort
+	if port == 0 {
+		port = defaultReadsbJSONPort
+	}
+	ReadLineStream("readsb json", net.JoinHostPort(config.ReadsbJSONHost, strconv.Itoa(port)), func(line string) {
+		HandleReadsbJSONLine(aircraftState, line)
+	})
+}
+
+// Applies one SBS-1 (BaseStation) message, ex:
+//
+//	MSG,3,1,1,A1B2C3,1,2024/01/01,12:00:00.000,2024/01/01,12:00:00.000,,35000,,,40.12345,-74.12345,,,0,0,0,0
+//
+// Fields past the hex are only filled in for the transmission types that
+// carry them.
+func HandleSBSLine(table *AircraftStateTable, line string) {
+	fields := strings.Split(strings.TrimSpace(line), ",")
+	if len(fields) < 11 || fields[0] != "MSG" {
+		return
+	}
+	field := func(i int) string {
+		if i < len(fields) {
+			return strings.TrimSpace(fields[i])
+		}
+		return ""
+	}
+	number := func(i int) (float64, bool) {
+		value, err := strconv.ParseFloat(field(i), 64)
+		return value, err == nil
+	}
+	flag := func(i int) bool {
+		// Some senders use -1 for true
+		return field(i) == "-1" || field(i) == "1"
+	}
+
+	table.Update(field(4), func(aircraft *TJSONAircraft) (hasPosition bool) {
+		if callsign := field(10); callsign != "" {
+			aircraft.AircraftTailCode = callsign
+		}
+		// Altitude and the ground flag come in different transmission
+		// types, so each only changes its own part. The flag goes first so
+		// an altitude in the same message counts after a takeoff.
+		if field(21) != "" {
+			onGround := flag(21)
+			if aircraft.AltimeterBarometer.OnGround && !onGround {
+				// Just took off, so the altitude isn't known until one is sent
+				aircraft.AltimeterBarometer.Known = false
+			}
+			aircraft.AltimeterBarometer.OnGround = onGround
+			if onGround {
+				aircraft.AltimeterBarometer.Known = true
+			}
+		}
+		if altitude, ok := number(11); ok {
+			aircraft.AltimeterBarometer.Feet = int64(altitude)
+			aircraft.AltimeterBarometer.Known = true
+		}
+		if speed, ok := number(12); ok {
+			aircraft.GroundSpeedKnots = speed
+		}
+		if track, ok := number(13); ok {
+			aircraft.Track = track
+		}
+		lat, latOK := number(14)
+		lon, lonOK := number(15)
+		if latOK && lonOK {
+			aircraft.Latitude, aircraft.Longitude = lat, lon
+			hasPosition = true
+		}
+		if rate, ok := number(16); ok {
+			aircraft.AltimeterBarometerRateFeet = rate
+		}
+		if squawk := field(17); squawk != "" {
+			aircraft.Squawk = squawk
+		}
+		if field(19) != "" {
+			aircraft.Emergency = "none"
+			if flag(19) {
+				aircraft.Emergency = "general"
+			}
+		}
+		aircraft.Type = "adsb_icao"
+		return hasPosition
+	})
+}
+
+// Applies one line from readsb's --net-json-port, which is a full
+// aircraft.json style object for one aircraft
+func HandleReadsbJSONLine(table *AircraftStateTable, line string) {
+	var update TJSONAircraft
+	if err := json.Unmarshal([]byte(line), &update); err != nil {
+		log.Debugf("error decoding readsb json: %v", err)
+		return
+	}
+	// Non-ICAO addresses keep their "~" so they can't replace a real aircraft
+	table.Update(update.Hex, func(aircraft *TJSONAircraft) (hasPosition bool) {
+		messages := aircraft.MessageCount
+		*aircraft = update
+		aircraft.MessageCount = messages
+		return update.SeenPosition < 1 && (update.Latitude != 0 || update.Longitude != 0)
+	})
+}
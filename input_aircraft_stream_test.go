@@ -0,0 +1,114 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHandleSBSLine(t *testing.T) {
+	table := NewAircraftStateTable(time.Minute)
+	lines := []string{
+		// Identification, then airborne position, then velocity
+		"MSG,1,1,1,A1B2C3,1,2024/01/01,12:00:00.000,2024/01/01,12:00:00.000,UAL123  ,,,,,,,,,,,0",
+		"MSG,3,1,1,A1B2C3,1,2024/01/01,12:00:01.000,2024/01/01,12:00:01.000,,35000,,,40.12345,-74.12345,,,0,0,0,0",
+		"MSG,4,1,1,A1B2C3,1,2024/01/01,12:00:02.000,2024/01/01,12:00:02.000,,,450,270,,,-64,,,,,0",
+		// Not a MSG line
+		"STA,,1,1,A1B2C3,1,2024/01/01,12:00:03.000,2024/01/01,12:00:03.000,RM",
+	}
+	for _, line := range lines {
+		HandleSBSLine(table, line)
+	}
+	aircraft, ok := table.LookupHex("a1b2c3")
+	if !ok {
+		t.Fatal("aircraft not found")
+	}
+	if aircraft.AircraftTailCode != "UAL123" || aircraft.Latitude != 40.12345 || aircraft.Longitude != -74.12345 ||
+		aircraft.GroundSpeedKnots != 450 || aircraft.Track != 270 || aircraft.AltimeterBarometerRateFeet != -64 {
+		t.Errorf("got %+v", aircraft)
+	}
+	// Later messages without an altitude don't wipe it out
+	if altitude := aircraft.AltimeterBarometer; altitude.Feet != 35000 || !altitude.Known || altitude.OnGround {
+		t.Errorf("altitude is %+v", altitude)
+	}
+	if _, ok := table.LookupCallsign("ual123"); !ok {
+		t.Error("callsign not found")
+	}
+	if age, ok := table.DataAge(); !ok || age > time.Second {
+		t.Errorf("data age is %s %t", age, ok)
+	}
+}
+
+func TestHandleSBSLineGround(t *testing.T) {
+	tests := []struct {
+		name       string
+		lines      []string
+		wantGround bool
+		wantKnown  bool
+		wantFeet   int64
+	}{
+		{
+			name:       "on the ground",
+			lines:      []string{"MSG,2,1,1,A1B2C3,1,,,,,,,12,90,40.1,-74.1,,,,,,-1"},
+			wantGround: true,
+			wantKnown:  true,
+		},
+		{
+			name: "takes off",
+			lines: []string{
+				"MSG,2,1,1,A1B2C3,1,,,,,,,12,90,40.1,-74.1,,,,,,-1",
+				"MSG,5,1,1,A1B2C3,1,,,,,,,,,,,,,0,,0,0",
+			},
+		},
+		{
+			name: "takes off and climbs",
+			lines: []string{
+				"MSG,2,1,1,A1B2C3,1,,,,,,,12,90,40.1,-74.1,,,,,,1",
+				"MSG,5,1,1,A1B2C3,1,,,,,,1500,,,,,,,0,,0,0",
+			},
+			wantKnown: true,
+			wantFeet:  1500,
+		},
+		{
+			name: "ground flag without an altitude keeps it",
+			lines: []string{
+				"MSG,3,1,1,A1B2C3,1,,,,,,12000,,,40.1,-74.1,,,,,,0",
+				"MSG,8,1,1,A1B2C3,1,,,,,,,,,,,,,,,,0",
+			},
+			wantKnown: true,
+			wantFeet:  12000,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			table := NewAircraftStateTable(time.Minute)
+			for _, line := range test.lines {
+				HandleSBSLine(table, line)
+			}
+			aircraft, _ := table.LookupHex("a1b2c3")
+			altitude := aircraft.AltimeterBarometer
+			if altitude.OnGround != test.wantGround || altitude.Known != test.wantKnown || altitude.Feet != test.wantFeet {
+				t.Errorf("got %+v", altitude)
+			}
+		})
+	}
+}
+
+func TestHandleReadsbJSONLine(t *testing.T) {
+	table := NewAircraftStateTable(time.Minute)
+	HandleReadsbJSONLine(table, `{"hex": "a1b2c3", "flight": "UAL123", "alt_baro": 35000, "lat": 40.1, "lon": -74.1, "seen_pos": 0.2}`)
+	HandleReadsbJSONLine(table, `{"hex": "~a1b2c3", "type": "tisb_other", "alt_baro": 1000, "lat": 41, "lon": -75, "seen_pos": 0.2}`)
+	HandleReadsbJSONLine(table, `not json`)
+	aircraft, ok := table.LookupHex("a1b2c3")
+	if !ok || aircraft.AltimeterBarometer.Feet != 35000 {
+		t.Errorf("ICAO aircraft got %+v", aircraft)
+	}
+	if _, ok := table.LookupHex("~a1b2c3"); !ok {
+		t.Error("non-ICAO aircraft not found")
+	}
+}
+
+func TestAircraftStateTableNoData(t *testing.T) {
+	if _, ok := NewAircraftStateTable(time.Minute).DataAge(); ok {
+		t.Error("expected no data age before any messages")
+	}
+}
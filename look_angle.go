@@ -0,0 +1,140 @@
+package main
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// WGS84 ellipsoid
+const (
+	wgs84SemiMajorAxisMeters = 6378137.0
+	wgs84Flattening          = 1 / 298.257223563
+	metersPerFoot            = 0.3048
+	// Radio horizon in km for a height in meters, using the usual 4/3 earth
+	// radius to allow for refraction
+	radioHorizonKmPerSqrtMeter = 4.12
+)
+
+var compassPoints = []string{
+	"N", "NNE", "NE", "ENE", "E", "ESE", "SE", "SSE",
+	"S", "SSW", "SW", "WSW", "W", "WNW", "NW", "NNW",
+}
+
+// Where the receiver's antenna is
+type Station struct {
+	Latitude  float64
+	Longitude float64
+	// Height of the antenna above sea level
+	AltitudeMeters float64
+	// Height of the ground at the station above sea level, and of the
+	// antenna above that ground
+	GroundMeters        float64
+	AntennaHeightMeters float64
+}
+
+// Parses a "LAT,LON" geolocation like the *_REFERENCE_GEOLOCATION settings
+func ParseGeolocation(geolocation string) (lat, lon float64, ok bool) {
+	coords := strings.Split(geolocation, ",")
+	if len(coords) != 2 {
+		return 0, 0, false
+	}
+	lat, latErr := strconv.ParseFloat(strings.TrimSpace(coords[0]), 64)
+	lon, lonErr := strconv.ParseFloat(strings.TrimSpace(coords[1]), 64)
+	if latErr != nil || lonErr != nil {
+		return 0, 0, false
+	}
+	return lat, lon, true
+}
+
+// The station from STATION_GEOLOCATION, falling back to the tar1090 or ADS-B
+// reference geolocation
+func StationLocation() (station Station, ok bool) {
+	for _, geolocation := range []string{
+		config.StationGeolocation,
+		config.TAR1090ReferenceGeolocation,
+		config.ADSBExchangeReferenceGeolocation,
+	} {
+		if geolocation == "" {
+			continue
+		}
+		if station.Latitude, station.Longitude, ok = ParseGeolocation(geolocation); ok {
+			station.GroundMeters = config.StationAltitudeMeters
+			station.AntennaHeightMeters = config.StationAntennaHeightMeters
+			station.AltitudeMeters = station.GroundMeters + station.AntennaHeightMeters
+			return station, true
+		}
+	}
+	return station, false
+}
+
+// Initial great circle bearing from one point to another, in degrees true
+func Bearing(lat1, lon1, lat2, lon2 float64) float64 {
+	lat1Rad, lat2Rad := lat1*math.Pi/180, lat2*math.Pi/180
+	dLon := (lon2 - lon1) * math.Pi / 180
+	y := math.Sin(dLon) * math.Cos(lat2Rad)
+	x := math.Cos(lat1Rad)*math.Sin(lat2Rad) - math.Sin(lat1Rad)*math.Cos(lat2Rad)*math.Cos(dLon)
+	return math.Mod(math.Atan2(y, x)*180/math.Pi+360, 360)
+}
+
+// ex: 22 gives "NNE"
+func CardinalDirection(bearing float64) string {
+	index := int(math.Floor(math.Mod(bearing, 360)/22.5+0.5)) % len(compassPoints)
+	if index < 0 {
+		index += len(compassPoints)
+	}
+	return compassPoints[index]
+}
+
+// Earth centered, earth fixed coordinates in meters
+func ecef(lat, lon, altitudeMeters float64) (x, y, z float64) {
+	latRad, lonRad := lat*math.Pi/180, lon*math.Pi/180
+	e2 := wgs84Flattening * (2 - wgs84Flattening)
+	n := wgs84SemiMajorAxisMeters / math.Sqrt(1-e2*math.Sin(latRad)*math.Sin(latRad))
+	x = (n + altitudeMeters) * math.Cos(latRad) * math.Cos(lonRad)
+	y = (n + altitudeMeters) * math.Cos(latRad) * math.Sin(lonRad)
+	z = (n*(1-e2) + altitudeMeters) * math.Sin(latRad)
+	return x, y, z
+}
+
+// Straight line distance to the aircraft and its angle above the station's
+// horizon. Negative elevations are below the horizon.
+//
+// Both altitudes are taken as heights above the WGS84 ellipsoid. The
+// station's is above sea level, and the aircraft's may be too, which is off
+// by the geoid separation (at most about 100 m). At the ranges VHF reaches
+// that moves the elevation by well under a degree.
+func SlantRangeAndElevation(station Station, lat, lon, altitudeMeters float64) (slantRangeMeters, elevationDegrees float64) {
+	sx, sy, sz := ecef(station.Latitude, station.Longitude, station.AltitudeMeters)
+	ax, ay, az := ecef(lat, lon, altitudeMeters)
+	dx, dy, dz := ax-sx, ay-sy, az-sz
+	slantRangeMeters = math.Sqrt(dx*dx + dy*dy + dz*dz)
+	if slantRangeMeters == 0 {
+		return 0, 0
+	}
+	latRad, lonRad := station.Latitude*math.Pi/180, station.Longitude*math.Pi/180
+	up := math.Cos(latRad)*math.Cos(lonRad)*dx + math.Cos(latRad)*math.Sin(lonRad)*dy + math.Sin(latRad)*dz
+	// Rounding can push this just past 1 straight overhead
+	sinElevation := math.Max(-1, math.Min(1, up/slantRangeMeters))
+	return slantRangeMeters, math.Asin(sinElevation) * 180 / math.Pi
+}
+
+// How far apart two antennas can be and still see each other over a smooth
+// earth. Heights are above the ground at the station, not sea level, since
+// that's the surface the horizon is on.
+func RadioHorizonKm(antennaMeters, aircraftMeters float64) float64 {
+	return radioHorizonKmPerSqrtMeter * (math.Sqrt(math.Max(antennaMeters, 0)) + math.Sqrt(math.Max(aircraftMeters, 0)))
+}
+
+// The point a distance away along a bearing, on a sphere, which is plenty
+// for drawing coverage
+func DestinationPoint(lat, lon, bearing, km float64) (destLat, destLon float64) {
+	const earthRadiusKm = 6371.0
+	lat1Rad, lon1Rad := lat*math.Pi/180, lon*math.Pi/180
+	bearingRad := bearing * math.Pi / 180
+	angular := km / earthRadiusKm
+	lat2Rad := math.Asin(math.Sin(lat1Rad)*math.Cos(angular) + math.Cos(lat1Rad)*math.Sin(angular)*math.Cos(bearingRad))
+	lon2Rad := lon1Rad + math.Atan2(math.Sin(bearingRad)*math.Sin(angular)*math.Cos(lat1Rad), math.Cos(angular)-math.Sin(lat1Rad)*math.Sin(lat2Rad))
+	destLon = math.Mod(lon2Rad*180/math.Pi+540, 360) - 180
+	return lat2Rad * 180 / math.Pi, destLon
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBearing(t *testing.T) {
+	tests := []struct {
+		lat1, lon1, lat2, lon2 float64
+		want                   float64
+	}{
+		{0, 0, 1, 0, 0},
+		{0, 0, 0, 1, 90},
+		{0, 0, -1, 0, 180},
+		{0, 0, 0, -1, 270},
+		// JFK to LHR heads out northeast
+		{40.6413, -73.7781, 51.47, -0.4543, 51.3},
+	}
+	for _, test := range tests {
+		if got := Bearing(test.lat1, test.lon1, test.lat2, test.lon2); math.Abs(got-test.want) > 0.1 {
+			t.Errorf("%v,%v to %v,%v: got %.2f, want %.1f", test.lat1, test.lon1, test.lat2, test.lon2, got, test.want)
+		}
+	}
+}
+
+func TestCardinalDirection(t *testing.T) {
+	tests := []struct {
+		bearing float64
+		want    string
+	}{
+		{0, "N"},
+		{11.2, "N"},
+		{11.3, "NNE"},
+		{22, "NNE"},
+		{90, "E"},
+		{200, "SSW"},
+		{348.8, "N"},
+		{360, "N"},
+		{-90, "W"},
+	}
+	for _, test := range tests {
+		if got := CardinalDirection(test.bearing); got != test.want {
+			t.Errorf("%v: got %q, want %q", test.bearing, got, test.want)
+		}
+	}
+}
+
+func TestSlantRangeAndElevation(t *testing.T) {
+	station := Station{Latitude: 40, Longitude: -74}
+	tests := []struct {
+		name           string
+		lat, lon       float64
+		altitudeMeters float64
+		slantMeters    float64
+		elevation      float64
+	}{
+		{"overhead", 40, -74, 10000, 10000, 90},
+		{"same place", 40, -74, 0, 0, 0},
+		// About 111 km north at 10 km up
+		{"north at altitude", 41, -74, 10000, 111500, 4.6},
+		// Curvature puts a distant aircraft at ground level below the horizon
+		{"north at ground level", 41, -74, 0, 111000, -0.5},
+	}
+	for _, test := range tests {
+		slant, elevation := SlantRangeAndElevation(station, test.lat, test.lon, test.altitudeMeters)
+		if math.Abs(slant-test.slantMeters) > 1000 || math.Abs(elevation-test.elevation) > 0.1 {
+			t.Errorf("%s: got %.0f m at %.2f degrees, want %.0f m at %.1f", test.name, slant, elevation, test.slantMeters, test.elevation)
+		}
+	}
+}
+
+func TestRadioHorizonKm(t *testing.T) {
+	tests := []struct {
+		antennaMeters, aircraftMeters, want float64
+	}{
+		{16, 10000, 428.48},
+		{0, 0, 0},
+		// An aircraft below the station's ground can't add anything
+		{16, -100, 16.48},
+	}
+	for _, test := range tests {
+		if got := RadioHorizonKm(test.antennaMeters, test.aircraftMeters); math.Abs(got-test.want) > 0.01 {
+			t.Errorf("%v, %v: got %.2f, want %.2f", test.antennaMeters, test.aircraftMeters, got, test.want)
+		}
+	}
+}
+
+func TestAnnotateLookAngle(t *testing.T) {
+	previous := config
+	t.Cleanup(func() { config = previous })
+	config.StationGeolocation = "42,-83"
+	config.StationAltitudeMeters = 1600
+	config.StationAntennaHeightMeters = 9
+	config.TAR1090URL, config.SBSHost, config.ReadsbJSONHost = "", "", ""
+
+	// A position with no altitude gets bearing and distance only
+	annotation := LookAngleAnnotator{}.AnnotateLookAngle(MessageAircraft{Registration: "N12345", Label: "H1", Text: "POS N4225.1W08230.1"})
+	if annotation == nil || annotation["lookAngleCardinal"] == nil {
+		t.Fatalf("got %v", annotation)
+	}
+	if _, ok := annotation["lookAngleElevationDegrees"]; ok {
+		t.Error("elevation given without an altitude")
+	}
+
+	annotation = LookAngleAnnotator{}.AnnotateLookAngle(MessageAircraft{Registration: "N12345", Label: "H1", Text: "#M1BPOSN42251W082301,DETRO,195631,330,CRL,200122,BORES,M42,295069,188"})
+	// The horizon is measured from the ground at the station, not sea level
+	want := RadioHorizonKm(9, 33000*metersPerFoot-1600)
+	if got, ok := annotation["lookAngleRadioHorizonKm"].(float64); !ok || math.Abs(got-want) > 0.01 {
+		t.Errorf("radio horizon is %v, want %.2f", annotation["lookAngleRadioHorizonKm"], want)
+	}
+	if annotation["lookAngleAircraftAltitudeFeet"] != int64(33000) {
+		t.Errorf("altitude is %v", annotation["lookAngleAircraftAltitudeFeet"])
+	}
+}
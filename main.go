@@ -48,9 +48,25 @@ func init() {
 }
 
 func main() {
+	if len(os.Args) == 3 && os.Args[1] == "evaluate" {
+		EvaluateLLMFilters(os.Args[2])
+		return
+	}
+
 	ConfigureAnnotators()
+	ConfigureTrackHistory()
+	ConfigureAircraftDatabase()
+	ConfigureAirportDatabase()
+	ConfigureLocationDatabase()
+	ConfigureTar1090Cache()
+	ConfigureAircraftState()
+	ConfigureReceptionStats()
 	ConfigureReceivers()
 	ConfigureFilters()
+	ConfigureLLMCache()
+	ConfigureLLMLimits()
+	ConfigureLLMExamples()
+	ConfigureHTTPServer()
 
 	go SubscribeToACARSHub()
 
@@ -59,4 +75,10 @@ func main() {
 	sc := make(chan os.Signal, 1)
 	signal.Notify(sc, syscall.SIGINT, syscall.SIGTERM, os.Interrupt)
 	<-sc
+	if llmCache != nil && config.LLMCacheFile != "" {
+		llmCache.Save(config.LLMCacheFile)
+	}
+	if receptionStats != nil && config.ReceptionStatsFile != "" {
+		receptionStats.Save(config.ReceptionStatsFile)
+	}
 }
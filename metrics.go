@@ -0,0 +1,47 @@
+package main
+
+import (
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Simple in-process counters, keyed by name
+type Metrics struct {
+	mu       sync.Mutex
+	counters map[string]int64
+}
+
+var metrics = &Metrics{counters: map[string]int64{}}
+
+// Add n to the named counter
+func (m *Metrics) Add(name string, n int64) {
+	m.mu.Lock()
+	m.counters[name] += n
+	value := m.counters[name]
+	m.mu.Unlock()
+	log.Debugf("metric %s is now %d", name, value)
+}
+
+// Add one to the named counter
+func (m *Metrics) Increment(name string) {
+	m.Add(name, 1)
+}
+
+// Returns the current value of the named counter
+func (m *Metrics) Get(name string) int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.counters[name]
+}
+
+// Returns a copy of all counters
+func (m *Metrics) Snapshot() map[string]int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	snapshot := make(map[string]int64, len(m.counters))
+	for k, v := range m.counters {
+		snapshot[k] = v
+	}
+	return snapshot
+}
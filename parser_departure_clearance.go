@@ -0,0 +1,64 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+)
+
+// Pre-departure clearances (PDC), label A3 or text with a PDC header on its
+// first line:
+//
+//	PDC 001 UAL123 KSFO ... CLEARED TO KLAX VIA SSTIK5 ... MAINT 5000
+//	... DEP FREQ 135.1 SQUAWK 4621
+//
+// "CLEARED TO" on its own isn't enough, it's also in landing and approach
+// clearances.
+type DepartureClearanceParser struct {
+}
+
+var (
+	pdcRegex            = regexp.MustCompile(`^[^\n]*\b(PDC|PRE-?DEPARTURE CLEARANCE|DEPARTURE CLEARANCE)\b`)
+	pdcOriginRegex      = regexp.MustCompile(`\bPDC\b.*?\b([A-Z]{4})\b`)
+	pdcDestinationRegex = regexp.MustCompile(`\b(?:CLEARED|CLRD) TO\s+(?:THE\s+)?([A-Z]{4})\b`)
+	pdcRouteRegex       = regexp.MustCompile(`\bVIA\s+(\S+)`)
+	pdcAltitudeRegex    = regexp.MustCompile(`\b(?:MAINT(?:AIN)?|CLIMB VIA SID EXCEPT MAINT(?:AIN)?)\s+(?:FL)?(\d{3,5})\b`)
+	pdcFrequencyRegex   = regexp.MustCompile(`\bDEP(?:ARTURE)?\s+FREQ(?:UENCY)?\s+(?:IS\s+)?(\d{3}\.\d{1,3})\b`)
+	pdcSquawkRegex      = regexp.MustCompile(`\b(?:SQUAWK|SQK|XPNDR|TRANSPONDER)\s+([0-7]{4})\b`)
+)
+
+func (p DepartureClearanceParser) Name() string {
+	return "departure clearance"
+}
+
+func (p DepartureClearanceParser) Parse(label, text string) Annotation {
+	if label != "A3" && !pdcRegex.MatchString(text) {
+		return nil
+	}
+	annotation := Annotation{
+		"parsedEventType": "departure clearance",
+	}
+	if match := pdcOriginRegex.FindStringSubmatch(text); match != nil {
+		annotation["parsedOrigin"] = match[1]
+	}
+	if match := pdcDestinationRegex.FindStringSubmatch(text); match != nil {
+		annotation["parsedDestination"] = match[1]
+	}
+	if match := pdcRouteRegex.FindStringSubmatch(text); match != nil {
+		annotation["parsedRoute"] = match[1]
+	}
+	if match := pdcAltitudeRegex.FindStringSubmatch(text); match != nil {
+		altitude, _ := strconv.ParseInt(match[1], 10, 64)
+		// Flight levels are reported in hundreds of feet
+		if altitude < 1000 {
+			altitude *= 100
+		}
+		annotation["parsedAltitude"] = altitude
+	}
+	if match := pdcFrequencyRegex.FindStringSubmatch(text); match != nil {
+		annotation["parsedDepartureFrequencyMHz"], _ = strconv.ParseFloat(match[1], 64)
+	}
+	if match := pdcSquawkRegex.FindStringSubmatch(text); match != nil {
+		annotation["parsedSquawk"] = match[1]
+	}
+	return annotation
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"regexp"
+)
+
+// ETA and arrival reports, either label Q2 (destination then time):
+//
+//	KLAX1645
+//
+// or free text:
+//
+//	ETA KLAX 1645
+//	ETA/1645
+type ETAParser struct {
+}
+
+var (
+	etaLabelRegex = regexp.MustCompile(`^([A-Z]{4})(\d{4})`)
+	etaTextRegex  = regexp.MustCompile(`\bETA\s*[/:]?\s*(?:([A-Z]{4})\s*[/:]?\s*)?(\d{4})Z?\b`)
+)
+
+func (p ETAParser) Name() string {
+	return "eta"
+}
+
+func (p ETAParser) Parse(label, text string) Annotation {
+	if label == "Q2" {
+		if match := etaLabelRegex.FindStringSubmatch(text); match != nil {
+			return Annotation{
+				"parsedEventType":   "eta",
+				"parsedDestination": match[1],
+				"parsedETA":         FormatACARSTime(match[2]),
+			}
+		}
+	}
+	if match := etaTextRegex.FindStringSubmatch(text); match != nil {
+		annotation := Annotation{
+			"parsedEventType": "eta",
+			"parsedETA":       FormatACARSTime(match[2]),
+		}
+		if match[1] != "" {
+			annotation["parsedDestination"] = match[1]
+		}
+		return annotation
+	}
+	return nil
+}
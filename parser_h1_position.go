@@ -0,0 +1,88 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// FMC position reports, usually label H1 with an M1 sublabel:
+//
+//	#M1BPOSN42251W082301,DETRO,195631,330,CRL,200122,BORES,M42,295069,188
+//
+// Position, reporting waypoint, time, flight level, next waypoint, ETA,
+// following waypoint, temperature, wind direction/speed, then airline fields
+type H1PositionParser struct {
+}
+
+var h1PositionRegex = regexp.MustCompile(`POS([NS])(\d{4,5})([EW])(\d{5,6}),?(.*)`)
+
+func (p H1PositionParser) Name() string {
+	return "h1 position report"
+}
+
+func (p H1PositionParser) Parse(label, text string) Annotation {
+	match := h1PositionRegex.FindStringSubmatch(text)
+	if match == nil {
+		return nil
+	}
+	lat, latOK := ParseDegreesMinutes(match[1], match[2], 2)
+	lon, lonOK := ParseDegreesMinutes(match[3], match[4], 3)
+	if !latOK || !lonOK {
+		return nil
+	}
+	annotation := Annotation{
+		"parsedEventType": "position",
+		"parsedLatitude":  lat,
+		"parsedLongitude": lon,
+	}
+
+	fields := strings.Split(match[5], ",")
+	field := func(i int) string {
+		if i < len(fields) {
+			return strings.TrimSpace(fields[i])
+		}
+		return ""
+	}
+	if waypoint := field(0); waypoint != "" {
+		annotation["parsedWaypoint"] = waypoint
+	}
+	if t := field(1); len(t) >= 4 {
+		annotation["parsedReportTime"] = FormatACARSTime(t)
+	}
+	if altitude, err := strconv.ParseInt(field(2), 10, 64); err == nil {
+		// Flight levels are reported in hundreds of feet
+		if altitude < 1000 {
+			altitude *= 100
+		}
+		annotation["parsedAltitude"] = altitude
+	}
+	if waypoint := field(3); waypoint != "" {
+		annotation["parsedNextWaypoint"] = waypoint
+	}
+	if t := field(4); len(t) >= 4 {
+		annotation["parsedNextWaypointETA"] = FormatACARSTime(t)
+	}
+	if temperature := field(6); len(temperature) > 1 {
+		sign := 1
+		switch temperature[0] {
+		case 'M', '-':
+			sign = -1
+		case 'P', '+':
+		default:
+			temperature = " " + temperature
+		}
+		if value, err := strconv.Atoi(temperature[1:]); err == nil {
+			annotation["parsedTemperatureC"] = sign * value
+		}
+	}
+	if wind := field(7); len(wind) == 6 {
+		direction, dirErr := strconv.Atoi(wind[:3])
+		speed, speedErr := strconv.Atoi(wind[3:])
+		if dirErr == nil && speedErr == nil {
+			annotation["parsedWindDirectionDegrees"] = direction
+			annotation["parsedWindSpeedKnots"] = speed
+		}
+	}
+	return annotation
+}
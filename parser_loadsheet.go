@@ -0,0 +1,61 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+)
+
+// Loadsheets uplinked before departure:
+//
+//	LOADSHEET FINAL 1234 EDNO 1
+//	KSFO KLAX UA123/19
+//	ZFW 52000 MAX 61000
+//	TOF 8000
+//	TOW 60000 MAX 73500
+//	TIF 4000
+//	LAW 56000 MAX 64500
+//	PAX/12/150 TTL 162
+type LoadsheetParser struct {
+}
+
+var (
+	loadsheetRegex       = regexp.MustCompile(`\bLOADSHEET\b`)
+	loadsheetRouteRegex  = regexp.MustCompile(`(?m)^\s*([A-Z]{3,4})\s*[-/ ]\s*([A-Z]{3,4})\b`)
+	loadsheetPaxRegex    = regexp.MustCompile(`\bPAX\s*/?\s*([\d/]+)(?:\s+TTL\s+(\d+))?`)
+	loadsheetWeightRegex = map[string]*regexp.Regexp{
+		"parsedZeroFuelWeight": regexp.MustCompile(`\bZFW\s*[:/]?\s*(\d+)`),
+		"parsedTakeoffFuel":    regexp.MustCompile(`\bTOF\s*[:/]?\s*(\d+)`),
+		"parsedTakeoffWeight":  regexp.MustCompile(`\bTOW\s*[:/]?\s*(\d+)`),
+		"parsedTripFuel":       regexp.MustCompile(`\bTIF\s*[:/]?\s*(\d+)`),
+		"parsedLandingWeight":  regexp.MustCompile(`\bLAW\s*[:/]?\s*(\d+)`),
+	}
+)
+
+func (p LoadsheetParser) Name() string {
+	return "loadsheet"
+}
+
+func (p LoadsheetParser) Parse(label, text string) Annotation {
+	if !loadsheetRegex.MatchString(text) {
+		return nil
+	}
+	annotation := Annotation{
+		"parsedEventType": "loadsheet",
+	}
+	if match := loadsheetRouteRegex.FindStringSubmatch(text); match != nil {
+		annotation["parsedOrigin"] = match[1]
+		annotation["parsedDestination"] = match[2]
+	}
+	for field, re := range loadsheetWeightRegex {
+		if match := re.FindStringSubmatch(text); match != nil {
+			annotation[field], _ = strconv.ParseInt(match[1], 10, 64)
+		}
+	}
+	if match := loadsheetPaxRegex.FindStringSubmatch(text); match != nil {
+		annotation["parsedPassengers"] = match[1]
+		if match[2] != "" {
+			annotation["parsedPassengersTotal"], _ = strconv.ParseInt(match[2], 10, 64)
+		}
+	}
+	return annotation
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"regexp"
+)
+
+// OOOI (out of the gate, off the ground, on the ground, into the gate) events.
+// The ARINC 620 Q labels start with origin, destination and time:
+//
+//	KORDKLAX1523 0123
+//
+// and some airlines send the event and time as text under a Q label:
+//
+//	OFF 1523
+type OOOIParser struct {
+}
+
+// Which event each Q label reports
+var OOOILabelEvents = map[string]string{
+	"QA": "out",
+	"QE": "out",
+	"QG": "out",
+	"QH": "out",
+	"QP": "out",
+	"QT": "out",
+	"QB": "off",
+	"QF": "off",
+	"QQ": "off",
+	"QC": "on",
+	"QK": "on",
+	"QR": "on",
+	"QD": "in",
+	"QL": "in",
+	"QS": "in",
+}
+
+var (
+	oooiLabelRegex = regexp.MustCompile(`^([A-Z]{4})([A-Z]{4})(\d{4})`)
+	oooiTextRegex  = regexp.MustCompile(`^(OUT|OFF|ON|IN)\s*[/:]?\s*(\d{4})Z?\b`)
+)
+
+func (p OOOIParser) Name() string {
+	return "oooi"
+}
+
+func (p OOOIParser) Parse(label, text string) Annotation {
+	// "ON 1234" and "OFF1523 FUEL" turn up in free text on other labels
+	event, ok := OOOILabelEvents[label]
+	if !ok {
+		return nil
+	}
+	if match := oooiLabelRegex.FindStringSubmatch(text); match != nil {
+		return Annotation{
+			"parsedEventType":   event,
+			"parsedOrigin":      match[1],
+			"parsedDestination": match[2],
+			"parsedEventTime":   FormatACARSTime(match[3]),
+		}
+	}
+	if match := oooiTextRegex.FindStringSubmatch(text); match != nil {
+		return Annotation{
+			"parsedEventType": map[string]string{"OUT": "out", "OFF": "off", "ON": "on", "IN": "in"}[match[1]],
+			"parsedEventTime": FormatACARSTime(match[2]),
+		}
+	}
+	return nil
+}
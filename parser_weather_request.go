@@ -0,0 +1,50 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Weather requests, label 5U or text like:
+//
+//	/WX KJFK KBOS
+//	REQ WX KJFK,KBOS
+type WeatherRequestParser struct {
+}
+
+var (
+	weatherRequestRegex = regexp.MustCompile(`^(?:/WX|REQ\s*WX|WXRQ|REQWX|METAR REQ)\b`)
+	icaoAirportRegex    = regexp.MustCompile(`\b[A-Z]{4}\b`)
+)
+
+// Words that look like airport codes but aren't
+var weatherRequestKeywords = map[string]bool{
+	"WXRQ":  true,
+	"REQW":  true,
+	"METAR": true,
+	"TAFS":  true,
+	"ATIS":  true,
+}
+
+func (p WeatherRequestParser) Name() string {
+	return "weather request"
+}
+
+func (p WeatherRequestParser) Parse(label, text string) Annotation {
+	if label != "5U" && !weatherRequestRegex.MatchString(text) {
+		return nil
+	}
+	stations := []string{}
+	for _, station := range icaoAirportRegex.FindAllString(text, -1) {
+		if !weatherRequestKeywords[station] {
+			stations = append(stations, station)
+		}
+	}
+	annotation := Annotation{
+		"parsedEventType": "weather request",
+	}
+	if len(stations) > 0 {
+		annotation["parsedWeatherStations"] = strings.Join(stations, ",")
+	}
+	return annotation
+}
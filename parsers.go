@@ -0,0 +1,91 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Parses one format of ACARS message text into structured fields. Fields
+// should be prefixed with "parsed" so they don't clash with other annotators.
+type MessageTextParser interface {
+	Name() string
+	// Returns nil if the text isn't in this parser's format
+	Parse(label, text string) Annotation
+}
+
+// Parsers are tried in order and the first one to recognize the text wins,
+// so put the most specific formats first
+var MessageTextParsers = []MessageTextParser{
+	H1PositionParser{},
+	OOOIParser{},
+	ETAParser{},
+	WeatherRequestParser{},
+	DepartureClearanceParser{},
+	LoadsheetParser{},
+}
+
+// Runs message text through the parsers, returning fields from the first
+// one that recognizes it
+func ParseMessageText(label, text string) Annotation {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+	for _, parser := range MessageTextParsers {
+		if annotation := parser.Parse(label, text); annotation != nil {
+			annotation["parsedFormat"] = parser.Name()
+			return annotation
+		}
+	}
+	return nil
+}
+
+// Converts coordinates in the compact degrees and minutes form used in ACARS
+// ("N42251" is 42 degrees 25.1 minutes north) to decimal degrees. degreeDigits
+// is 2 for latitude and 3 for longitude.
+func ParseDegreesMinutes(hemisphere, digits string, degreeDigits int) (float64, bool) {
+	if len(digits) <= degreeDigits {
+		return 0, false
+	}
+	degrees, err := strconv.ParseFloat(digits[:degreeDigits], 64)
+	if err != nil {
+		return 0, false
+	}
+	minutesDigits := digits[degreeDigits:]
+	if !strings.Contains(minutesDigits, ".") && len(minutesDigits) > 2 {
+		minutesDigits = minutesDigits[:2] + "." + minutesDigits[2:]
+	}
+	minutes, err := strconv.ParseFloat(minutesDigits, 64)
+	if err != nil || minutes >= 60 {
+		return 0, false
+	}
+	value := degrees + minutes/60
+	if hemisphere == "S" || hemisphere == "W" {
+		value = -value
+	}
+	return value, true
+}
+
+// ex: "N4012.3W07412.5", "POSN40123W074125" or "N4012.3 W07412.5"
+var textPositionRegex = regexp.MustCompile(`(?:\b|POS)([NS])\s?(\d{4,5}(?:\.\d+)?)\s?,?\s?([EW])\s?(\d{5,6}(?:\.\d+)?)\b`)
+
+// Finds the first degrees and minutes position in message text
+func ExtractPositionFromText(text string) (lat, lon float64, found bool) {
+	for _, match := range textPositionRegex.FindAllStringSubmatch(text, -1) {
+		lat, latOK := ParseDegreesMinutes(match[1], match[2], 2)
+		lon, lonOK := ParseDegreesMinutes(match[3], match[4], 3)
+		if latOK && lonOK && lat >= -90 && lat <= 90 && lon >= -180 && lon <= 180 {
+			return lat, lon, true
+		}
+	}
+	return 0, 0, false
+}
+
+// Returns "HH:MM" for a "HHMM" or "HHMMSS" time
+func FormatACARSTime(t string) string {
+	if len(t) < 4 {
+		return t
+	}
+	return t[:2] + ":" + t[2:4]
+}
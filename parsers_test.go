@@ -0,0 +1,239 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+// Each fixture is a message as it comes off the air and the fields the
+// parsers should pull out of it. A nil want means nothing should match.
+var messageTextFixtures = []struct {
+	name  string
+	label string
+	text  string
+	want  Annotation
+}{
+	{
+		name:  "h1 position report",
+		label: "H1",
+		text:  "#M1BPOSN42251W082301,DETRO,195631,330,CRL,200122,BORES,M42,295069,188",
+		want: Annotation{
+			"parsedFormat":               "h1 position report",
+			"parsedEventType":            "position",
+			"parsedLatitude":             42.418333,
+			"parsedLongitude":            -82.501667,
+			"parsedWaypoint":             "DETRO",
+			"parsedReportTime":           "19:56",
+			"parsedAltitude":             int64(33000),
+			"parsedNextWaypoint":         "CRL",
+			"parsedNextWaypointETA":      "20:01",
+			"parsedTemperatureC":         -42,
+			"parsedWindDirectionDegrees": 295,
+			"parsedWindSpeedKnots":       69,
+		},
+	},
+	{
+		name:  "oooi off label",
+		label: "QB",
+		text:  "KORDKLAX1523 0123",
+		want: Annotation{
+			"parsedFormat":      "oooi",
+			"parsedEventType":   "off",
+			"parsedOrigin":      "KORD",
+			"parsedDestination": "KLAX",
+			"parsedEventTime":   "15:23",
+		},
+	},
+	{
+		name:  "oooi in label",
+		label: "QD",
+		text:  "KSFOKSEA2210 0250",
+		want: Annotation{
+			"parsedFormat":      "oooi",
+			"parsedEventType":   "in",
+			"parsedOrigin":      "KSFO",
+			"parsedDestination": "KSEA",
+		},
+	},
+	{
+		name:  "oooi text under a q label",
+		label: "QF",
+		text:  "OFF 1523",
+		want: Annotation{
+			"parsedFormat":    "oooi",
+			"parsedEventType": "off",
+			"parsedEventTime": "15:23",
+		},
+	},
+	{
+		name:  "free text on isn't oooi",
+		label: "H1",
+		text:  "ON 1234 NOTED THANKS",
+		want:  nil,
+	},
+	{
+		name:  "free text off with fuel isn't oooi",
+		label: "80",
+		text:  "OFF1523 FUEL 12.4",
+		want:  nil,
+	},
+	{
+		name:  "eta label",
+		label: "Q2",
+		text:  "KLAX1645",
+		want: Annotation{
+			"parsedFormat":      "eta",
+			"parsedEventType":   "eta",
+			"parsedDestination": "KLAX",
+			"parsedETA":         "16:45",
+		},
+	},
+	{
+		name:  "eta text",
+		label: "5Z",
+		text:  "ETA KDEN 0412Z",
+		want: Annotation{
+			"parsedFormat":      "eta",
+			"parsedEventType":   "eta",
+			"parsedDestination": "KDEN",
+			"parsedETA":         "04:12",
+		},
+	},
+	{
+		name:  "weather request",
+		label: "5U",
+		text:  "/WX KJFK KBOS",
+		want: Annotation{
+			"parsedFormat":          "weather request",
+			"parsedEventType":       "weather request",
+			"parsedWeatherStations": "KJFK,KBOS",
+		},
+	},
+	{
+		name:  "departure clearance",
+		label: "H1",
+		text:  "PDC 001 UAL123 KSFO\nCLEARED TO KLAX VIA SSTIK5 TRANSITION\nMAINT 5000 DEP FREQ 135.1 SQUAWK 4621",
+		want: Annotation{
+			"parsedFormat":                "departure clearance",
+			"parsedEventType":             "departure clearance",
+			"parsedOrigin":                "KSFO",
+			"parsedDestination":           "KLAX",
+			"parsedRoute":                 "SSTIK5",
+			"parsedAltitude":              int64(5000),
+			"parsedDepartureFrequencyMHz": 135.1,
+			"parsedSquawk":                "4621",
+		},
+	},
+	{
+		name:  "departure clearance label",
+		label: "A3",
+		text:  "UAL456 CLRD TO KORD VIA RNAV DCT MAINT FL230 SQK 2345",
+		want: Annotation{
+			"parsedFormat":      "departure clearance",
+			"parsedDestination": "KORD",
+			"parsedAltitude":    int64(23000),
+			"parsedSquawk":      "2345",
+		},
+	},
+	{
+		name:  "landing clearance isn't a departure clearance",
+		label: "20",
+		text:  "CLEARED TO LAND RWY 27L WIND 270/10",
+		want:  nil,
+	},
+	{
+		name:  "loadsheet",
+		label: "C1",
+		text:  "LOADSHEET FINAL 1234 EDNO 1\nKSFO KLAX UA123/19\nZFW 52000 MAX 61000\nTOF 8000\nTOW 60000 MAX 73500\nTIF 4000\nLAW 56000 MAX 64500\nPAX/12/150 TTL 162",
+		want: Annotation{
+			"parsedFormat":          "loadsheet",
+			"parsedEventType":       "loadsheet",
+			"parsedOrigin":          "KSFO",
+			"parsedDestination":     "KLAX",
+			"parsedZeroFuelWeight":  int64(52000),
+			"parsedTakeoffFuel":     int64(8000),
+			"parsedTakeoffWeight":   int64(60000),
+			"parsedTripFuel":        int64(4000),
+			"parsedLandingWeight":   int64(56000),
+			"parsedPassengers":      "12/150",
+			"parsedPassengersTotal": int64(162),
+		},
+	},
+	{
+		name:  "blank",
+		label: "H1",
+		text:  "   ",
+		want:  nil,
+	},
+}
+
+func TestParseMessageText(t *testing.T) {
+	for _, fixture := range messageTextFixtures {
+		t.Run(fixture.name, func(t *testing.T) {
+			got := ParseMessageText(fixture.label, fixture.text)
+			if fixture.want == nil {
+				if got != nil {
+					t.Fatalf("expected no match, got %v", got)
+				}
+				return
+			}
+			if got == nil {
+				t.Fatal("expected a match, got nothing")
+			}
+			for field, want := range fixture.want {
+				if !annotationValueEqual(got[field], want) {
+					t.Errorf("%s: got %v (%T), want %v (%T)", field, got[field], got[field], want, want)
+				}
+			}
+		})
+	}
+}
+
+// Floats are compared to six decimal places so fixtures stay readable
+func annotationValueEqual(got, want any) bool {
+	if wantFloat, ok := want.(float64); ok {
+		gotFloat, ok := got.(float64)
+		return ok && math.Abs(gotFloat-wantFloat) < 1e-6
+	}
+	return got == want
+}
+
+func TestParseDegreesMinutes(t *testing.T) {
+	tests := []struct {
+		hemisphere   string
+		digits       string
+		degreeDigits int
+		want         float64
+		ok           bool
+	}{
+		{"N", "42251", 2, 42.418333, true},
+		{"W", "082301", 3, -82.501667, true},
+		{"S", "3352.5", 2, -33.875, true},
+		{"N", "4275", 2, 0, false},
+		{"N", "42", 2, 0, false},
+	}
+	for _, test := range tests {
+		got, ok := ParseDegreesMinutes(test.hemisphere, test.digits, test.degreeDigits)
+		if ok != test.ok || (ok && math.Abs(got-test.want) > 1e-6) {
+			t.Errorf("%s%s: got %f %t, want %f %t", test.hemisphere, test.digits, got, ok, test.want, test.ok)
+		}
+	}
+}
+
+func TestExtractPositionFromText(t *testing.T) {
+	tests := []struct {
+		text     string
+		lat, lon float64
+		found    bool
+	}{
+		{"POS N4012.3W07412.5 FL350", 40.205, -74.208333, true},
+		{"REPORTING N4012.3 W07412.5", 40.205, -74.208333, true},
+		{"NO POSITION HERE", 0, 0, false},
+	}
+	for _, test := range tests {
+		lat, lon, found := ExtractPositionFromText(test.text)
+		if found != test.found || math.Abs(lat-test.lat) > 1e-6 || math.Abs(lon-test.lon) > 1e-6 {
+			t.Errorf("%q: got %f,%f %t", test.text, lat, lon, found)
+		}
+	}
+}
@@ -56,8 +56,12 @@ func (d DiscordHandlerReciever) SubmitACARSAnnotations(a Annotation) error {
 		content = fmt.Sprintf("%s\n**%s**: %v", content, key, a[key])
 	}
 
+	heading := "# ACARS Message\n"
+	if location, ok := a["locationDescription"].(string); ok && location != "" {
+		heading += "*" + location + "*\n"
+	}
 	message := DiscordWebhookMessage{
-		Content: "# ACARS Message\n" + content,
+		Content: heading + content,
 	}
 
 	buff := new(bytes.Buffer)
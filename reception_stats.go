@@ -0,0 +1,345 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultReceptionSectorDegrees  = 10
+	defaultReceptionSignalBucketDB = 10
+	defaultReceptionRecentCount    = 500
+	receptionStatsSaveInterval     = 5 * time.Minute
+	receptionArcStepDegrees        = 5.0
+)
+
+// One message with a known aircraft position
+type ReceptionRecord struct {
+	Time           time.Time `json:"time"`
+	Registration   string    `json:"registration,omitempty"`
+	Latitude       float64   `json:"latitude"`
+	Longitude      float64   `json:"longitude"`
+	DistanceKm     float64   `json:"distanceKm"`
+	BearingDegrees float64   `json:"bearingDegrees"`
+	SignalLevel    float64   `json:"signalLevel"`
+}
+
+// Furthest reception in one slice of the compass
+type ReceptionSector struct {
+	Count      int64   `json:"count"`
+	MaxRangeKm float64 `json:"maxRangeKm"`
+	// Keyed by the bottom of the signal level bucket, ex: "-20" for -20 to
+	// -10 with 10 dB buckets
+	MaxRangeKmBySignal map[string]float64 `json:"maxRangeKmBySignal"`
+}
+
+// Coverage for one station on one frequency
+type ReceptionSource struct {
+	StationID    string            `json:"stationId"`
+	FrequencyMHz float64           `json:"frequencyMHz"`
+	MessageCount int64             `json:"messageCount"`
+	MaxRangeKm   float64           `json:"maxRangeKm"`
+	TotalRangeKm float64           `json:"totalRangeKm"`
+	FirstSeen    time.Time         `json:"firstSeen"`
+	LastSeen     time.Time         `json:"lastSeen"`
+	Sectors      []ReceptionSector `json:"sectors"`
+	Recent       []ReceptionRecord `json:"recent"`
+}
+
+// Polar coverage histograms for every station and frequency messages have
+// come in on
+type ReceptionStats struct {
+	mu             sync.Mutex
+	sectorDegrees  float64
+	signalBucketDB float64
+	maxRecent      int
+	sources        map[string]*ReceptionSource
+	dirty          bool
+}
+
+var receptionStats *ReceptionStats
+
+func ConfigureReceptionStats() {
+	if !config.AnnotateReception {
+		return
+	}
+	sectorDegrees := config.ReceptionSectorDegrees
+	if sectorDegrees <= 0 || 360%sectorDegrees != 0 {
+		if sectorDegrees != 0 {
+			log.Warnf("RECEPTION_SECTOR_DEGREES must divide 360, using %d", defaultReceptionSectorDegrees)
+		}
+		sectorDegrees = defaultReceptionSectorDegrees
+	}
+	signalBucketDB := config.ReceptionSignalBucketDB
+	if signalBucketDB <= 0 {
+		signalBucketDB = defaultReceptionSignalBucketDB
+	}
+	recent := config.ReceptionRecentPositions
+	if recent == 0 {
+		recent = defaultReceptionRecentCount
+	}
+	receptionStats = NewReceptionStats(sectorDegrees, signalBucketDB, recent)
+	if config.ReceptionStatsFile != "" {
+		receptionStats.Load(config.ReceptionStatsFile)
+		go receptionStats.SaveEvery(config.ReceptionStatsFile, receptionStatsSaveInterval)
+	}
+}
+
+func NewReceptionStats(sectorDegrees int, signalBucketDB float64, maxRecent int) *ReceptionStats {
+	return &ReceptionStats{
+		sectorDegrees:  float64(sectorDegrees),
+		signalBucketDB: signalBucketDB,
+		maxRecent:      maxRecent,
+		sources:        map[string]*ReceptionSource{},
+	}
+}
+
+func receptionSourceKey(stationID string, frequencyMHz float64) string {
+	return fmt.Sprintf("%s/%.3f", stationID, frequencyMHz)
+}
+
+// The bucket a signal level falls in, ex: -17.5 is "-20" with 10 dB buckets
+func (s *ReceptionStats) SignalBucket(level float64) string {
+	return fmt.Sprintf("%g", math.Floor(level/s.signalBucketDB)*s.signalBucketDB)
+}
+
+func (s *ReceptionStats) sectorCount() int {
+	return int(360 / s.sectorDegrees)
+}
+
+// Adds a message to the station's coverage. Returns the sector it fell in,
+// the furthest range seen in that sector and whether this message set it.
+func (s *ReceptionStats) Record(stationID string, frequencyMHz float64, record ReceptionRecord) (sector int, sectorMaxKm float64, newRecord bool) {
+	if s == nil {
+		return 0, 0, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := receptionSourceKey(stationID, frequencyMHz)
+	source, ok := s.sources[key]
+	if !ok {
+		source = &ReceptionSource{
+			StationID:    stationID,
+			FrequencyMHz: frequencyMHz,
+			FirstSeen:    record.Time,
+			Sectors:      make([]ReceptionSector, s.sectorCount()),
+		}
+		s.sources[key] = source
+	}
+	s.dirty = true
+	source.MessageCount++
+	source.TotalRangeKm += record.DistanceKm
+	source.MaxRangeKm = math.Max(source.MaxRangeKm, record.DistanceKm)
+	source.LastSeen = record.Time
+	if s.maxRecent > 0 {
+		source.Recent = append(source.Recent, record)
+		if len(source.Recent) > s.maxRecent {
+			source.Recent = source.Recent[len(source.Recent)-s.maxRecent:]
+		}
+	}
+
+	sector = int(math.Mod(record.BearingDegrees, 360)/s.sectorDegrees) % len(source.Sectors)
+	histogram := &source.Sectors[sector]
+	histogram.Count++
+	if record.DistanceKm > histogram.MaxRangeKm {
+		histogram.MaxRangeKm = record.DistanceKm
+		newRecord = true
+	}
+	if histogram.MaxRangeKmBySignal == nil {
+		histogram.MaxRangeKmBySignal = map[string]float64{}
+	}
+	bucket := s.SignalBucket(record.SignalLevel)
+	histogram.MaxRangeKmBySignal[bucket] = math.Max(histogram.MaxRangeKmBySignal[bucket], record.DistanceKm)
+	return sector, histogram.MaxRangeKm, newRecord
+}
+
+// A copy of every source, sorted by station and frequency
+func (s *ReceptionStats) Snapshot() []ReceptionSource {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sources := make([]ReceptionSource, 0, len(s.sources))
+	for _, source := range s.sources {
+		copied := *source
+		copied.Sectors = make([]ReceptionSector, len(source.Sectors))
+		for i, sector := range source.Sectors {
+			copied.Sectors[i] = sector
+			copied.Sectors[i].MaxRangeKmBySignal = make(map[string]float64, len(sector.MaxRangeKmBySignal))
+			for bucket, km := range sector.MaxRangeKmBySignal {
+				copied.Sectors[i].MaxRangeKmBySignal[bucket] = km
+			}
+		}
+		copied.Recent = append([]ReceptionRecord(nil), source.Recent...)
+		sources = append(sources, copied)
+	}
+	sort.Slice(sources, func(i, j int) bool {
+		if sources[i].StationID != sources[j].StationID {
+			return sources[i].StationID < sources[j].StationID
+		}
+		return sources[i].FrequencyMHz < sources[j].FrequencyMHz
+	})
+	return sources
+}
+
+// Loads saved coverage from a file relative to $HOME
+func (s *ReceptionStats) Load(filePath string) {
+	contents := ReadFile(filePath)
+	if len(contents) == 0 {
+		return
+	}
+	var sources []ReceptionSource
+	if err := json.Unmarshal(contents, &sources); err != nil {
+		log.Warnf("error reading reception stats file, starting empty: %s", err)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range sources {
+		// Saved with a different sector size, so the histogram can't be reused
+		if len(sources[i].Sectors) != s.sectorCount() {
+			sources[i].Sectors = make([]ReceptionSector, s.sectorCount())
+		}
+		s.sources[receptionSourceKey(sources[i].StationID, sources[i].FrequencyMHz)] = &sources[i]
+	}
+	log.Infof("loaded reception stats for %d stations and frequencies", len(sources))
+}
+
+// Saves coverage to a file relative to $HOME if it has changed
+func (s *ReceptionStats) Save(filePath string) {
+	s.mu.Lock()
+	if !s.dirty {
+		s.mu.Unlock()
+		return
+	}
+	s.dirty = false
+	s.mu.Unlock()
+
+	contents, err := json.Marshal(s.Snapshot())
+	if err != nil {
+		log.Errorf("error encoding reception stats: %s", err)
+		return
+	}
+	WriteFile(filePath, contents)
+}
+
+func (s *ReceptionStats) SaveEvery(filePath string, interval time.Duration) {
+	for range time.Tick(interval) {
+		s.Save(filePath)
+	}
+}
+
+type receptionStatsResponse struct {
+	StationLatitude  float64           `json:"stationLatitude"`
+	StationLongitude float64           `json:"stationLongitude"`
+	SectorDegrees    float64           `json:"sectorDegrees"`
+	SignalBucketDB   float64           `json:"signalBucketDB"`
+	Sources          []ReceptionSource `json:"sources"`
+}
+
+type geoJSONFeature struct {
+	Type       string         `json:"type"`
+	Geometry   map[string]any `json:"geometry"`
+	Properties map[string]any `json:"properties"`
+}
+
+// Coverage as GeoJSON: the station, an outline of the furthest reception in
+// each sector for each source and signal bucket, and the recent positions
+func (s *ReceptionStats) GeoJSON(station Station) map[string]any {
+	features := []geoJSONFeature{{
+		Type:       "Feature",
+		Geometry:   map[string]any{"type": "Point", "coordinates": []float64{station.Longitude, station.Latitude}},
+		Properties: map[string]any{"type": "station"},
+	}}
+	for _, source := range s.Snapshot() {
+		properties := func(signal string) map[string]any {
+			return map[string]any{
+				"type":         "coverage",
+				"stationId":    source.StationID,
+				"frequencyMHz": source.FrequencyMHz,
+				"signal":       signal,
+				"messageCount": source.MessageCount,
+				"maxRangeKm":   source.MaxRangeKm,
+			}
+		}
+		ranges := make([]float64, len(source.Sectors))
+		buckets := map[string]bool{}
+		for i, sector := range source.Sectors {
+			ranges[i] = sector.MaxRangeKm
+			for bucket := range sector.MaxRangeKmBySignal {
+				buckets[bucket] = true
+			}
+		}
+		features = append(features, geoJSONFeature{
+			Type:       "Feature",
+			Geometry:   map[string]any{"type": "Polygon", "coordinates": [][][]float64{s.coverageRing(station, ranges)}},
+			Properties: properties("all"),
+		})
+		for bucket := range buckets {
+			for i, sector := range source.Sectors {
+				ranges[i] = sector.MaxRangeKmBySignal[bucket]
+			}
+			features = append(features, geoJSONFeature{
+				Type:       "Feature",
+				Geometry:   map[string]any{"type": "Polygon", "coordinates": [][][]float64{s.coverageRing(station, ranges)}},
+				Properties: properties(bucket),
+			})
+		}
+		for _, record := range source.Recent {
+			features = append(features, geoJSONFeature{
+				Type:     "Feature",
+				Geometry: map[string]any{"type": "Point", "coordinates": []float64{record.Longitude, record.Latitude}},
+				Properties: map[string]any{
+					"type":           "reception",
+					"stationId":      source.StationID,
+					"frequencyMHz":   source.FrequencyMHz,
+					"time":           record.Time,
+					"registration":   record.Registration,
+					"distanceKm":     record.DistanceKm,
+					"bearingDegrees": record.BearingDegrees,
+					"signalLevel":    record.SignalLevel,
+				},
+			})
+		}
+	}
+	return map[string]any{"type": "FeatureCollection", "features": features}
+}
+
+// Traces each sector's arc at its furthest range so the polygon looks like
+// a polar plot. Sectors with nothing in them pull back to the station.
+func (s *ReceptionStats) coverageRing(station Station, ranges []float64) [][]float64 {
+	// Wide sectors get extra points so the arc doesn't become a chord
+	steps := int(math.Ceil(s.sectorDegrees / receptionArcStepDegrees))
+	ring := make([][]float64, 0, (steps+1)*len(ranges)+1)
+	for i, km := range ranges {
+		for step := 0; step <= steps; step++ {
+			bearing := (float64(i) + float64(step)/float64(steps)) * s.sectorDegrees
+			lat, lon := DestinationPoint(station.Latitude, station.Longitude, bearing, km)
+			ring = append(ring, []float64{lon, lat})
+		}
+	}
+	return append(ring, ring[0])
+}
+
+// Adds /reception.json and /reception.geojson to mux
+func HandleReceptionStats(mux *http.ServeMux) {
+	mux.HandleFunc("/reception.json", func(w http.ResponseWriter, r *http.Request) {
+		station, _ := StationLocation()
+		writeJSON(w, "application/json", receptionStatsResponse{
+			StationLatitude:  station.Latitude,
+			StationLongitude: station.Longitude,
+			SectorDegrees:    receptionStats.sectorDegrees,
+			SignalBucketDB:   receptionStats.signalBucketDB,
+			Sources:          receptionStats.Snapshot(),
+		})
+	})
+	mux.HandleFunc("/reception.geojson", func(w http.ResponseWriter, r *http.Request) {
+		station, _ := StationLocation()
+		writeJSON(w, "application/geo+json", receptionStats.GeoJSON(station))
+	})
+}
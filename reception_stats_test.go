@@ -0,0 +1,168 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSignalBucket(t *testing.T) {
+	s := NewReceptionStats(10, 10, 0)
+	for level, want := range map[float64]string{-17.5: "-20", -20: "-20", -0.5: "-10", 3: "0"} {
+		if got := s.SignalBucket(level); got != want {
+			t.Errorf("%g: got %q, want %q", level, got, want)
+		}
+	}
+}
+
+func TestReceptionStatsRecord(t *testing.T) {
+	s := NewReceptionStats(90, 10, 2)
+	tests := []struct {
+		bearing, km, signal float64
+		wantSector          int
+		wantMax             float64
+		wantRecord          bool
+	}{
+		{45, 100, -15, 0, 100, true},
+		{80, 50, -25, 0, 100, false},
+		{359.9, 200, -15, 3, 200, true},
+		{360, 150, -15, 0, 150, true},
+	}
+	for i, test := range tests {
+		sector, max, record := s.Record("STN", 136.9, ReceptionRecord{
+			Time:           time.Unix(int64(i), 0),
+			DistanceKm:     test.km,
+			BearingDegrees: test.bearing,
+			SignalLevel:    test.signal,
+		})
+		if sector != test.wantSector || max != test.wantMax || record != test.wantRecord {
+			t.Errorf("message %d: got sector %d, %g km, record %t", i+1, sector, max, record)
+		}
+	}
+
+	sources := s.Snapshot()
+	if len(sources) != 1 {
+		t.Fatalf("got %d sources", len(sources))
+	}
+	source := sources[0]
+	if source.MessageCount != 4 || source.MaxRangeKm != 200 || len(source.Recent) != 2 || source.Recent[1].DistanceKm != 150 {
+		t.Errorf("got %+v", source)
+	}
+	if got := source.Sectors[0].MaxRangeKmBySignal; got["-20"] != 150 || got["-30"] != 50 {
+		t.Errorf("got signal ranges %v", got)
+	}
+	// The snapshot is a copy
+	source.Sectors[0].MaxRangeKmBySignal["-20"] = 1
+	if s.Snapshot()[0].Sectors[0].MaxRangeKmBySignal["-20"] != 150 {
+		t.Error("changing a snapshot changed the stats")
+	}
+
+	var disabled *ReceptionStats
+	if _, _, record := disabled.Record("STN", 136.9, ReceptionRecord{DistanceKm: 1}); record {
+		t.Error("nil stats recorded a message")
+	}
+}
+
+func TestReceptionStatsSaveAndLoad(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	s := NewReceptionStats(90, 10, 10)
+	s.Record("STN", 136.9, ReceptionRecord{DistanceKm: 100, BearingDegrees: 10})
+	s.Save("reception.json")
+
+	loaded := NewReceptionStats(90, 10, 10)
+	loaded.Load("reception.json")
+	if sources := loaded.Snapshot(); len(sources) != 1 || sources[0].Sectors[0].MaxRangeKm != 100 {
+		t.Errorf("got %+v", sources)
+	}
+	// A different sector size can't reuse the histogram, but keeps the totals
+	resized := NewReceptionStats(10, 10, 10)
+	resized.Load("reception.json")
+	if sources := resized.Snapshot(); len(sources) != 1 || len(sources[0].Sectors) != 36 || sources[0].MaxRangeKm != 100 || sources[0].Sectors[0].MaxRangeKm != 0 {
+		t.Errorf("got %+v", sources)
+	}
+}
+
+func TestReceptionStatsGeoJSON(t *testing.T) {
+	s := NewReceptionStats(90, 10, 10)
+	s.Record("STN", 136.9, ReceptionRecord{DistanceKm: 100, BearingDegrees: 10, SignalLevel: -15})
+	s.Record("STN", 136.9, ReceptionRecord{DistanceKm: 50, BearingDegrees: 100, SignalLevel: -25})
+	collection := s.GeoJSON(Station{Latitude: 40, Longitude: -74})
+
+	features := collection["features"].([]geoJSONFeature)
+	counts := map[string]int{}
+	for _, feature := range features {
+		counts[feature.Properties["type"].(string)]++
+		if feature.Geometry["type"] != "Polygon" {
+			continue
+		}
+		ring := feature.Geometry["coordinates"].([][][]float64)[0]
+		first, last := ring[0], ring[len(ring)-1]
+		if first[0] != last[0] || first[1] != last[1] {
+			t.Errorf("ring for %v isn't closed", feature.Properties["signal"])
+		}
+		// 90 degree sectors are traced in 5 degree steps
+		if len(ring) != 4*19+1 {
+			t.Errorf("ring has %d points", len(ring))
+		}
+	}
+	// All signals plus the -20 and -30 buckets
+	if counts["station"] != 1 || counts["coverage"] != 3 || counts["reception"] != 2 {
+		t.Errorf("got %v", counts)
+	}
+}
+
+func TestAnnotateReception(t *testing.T) {
+	previous, previousStats := config, receptionStats
+	t.Cleanup(func() { config, receptionStats = previous, previousStats })
+	config.TAR1090URL, config.SBSHost, config.ReadsbJSONHost = "", "", ""
+	config.StationGeolocation = "40,-74"
+	receptionStats = NewReceptionStats(10, 10, 10)
+
+	// Due north of the station
+	aircraft := MessageAircraft{Registration: ".N12345", Label: "H1", Text: "POS N4100.0W07400.0"}
+	annotation := ReceptionAnnotator{}.AnnotateReception("STN", 136.9, -17.5, aircraft)
+	if annotation["receptionSector"] != 0 || annotation["receptionSectorRecord"] != true || annotation["receptionSignalBucket"] != "-20" {
+		t.Errorf("got %v", annotation)
+	}
+	if recent := receptionStats.Snapshot()[0].Recent; len(recent) != 1 || recent[0].Registration != "N12345" {
+		t.Errorf("got recent %+v", recent)
+	}
+	if got := (ReceptionAnnotator{}).AnnotateReception("STN", 136.9, -17.5, MessageAircraft{Text: "HELLO"}); got != nil {
+		t.Errorf("got %v without a position", got)
+	}
+}
+
+func TestReceptionOnlyRecordsDownlinks(t *testing.T) {
+	previous, previousStats := config, receptionStats
+	t.Cleanup(func() { config, receptionStats = previous, previousStats })
+	config.TAR1090URL, config.SBSHost, config.ReadsbJSONHost = "", "", ""
+	config.StationGeolocation = "40,-74"
+	receptionStats = NewReceptionStats(10, 10, 10)
+
+	for _, blockID := range []string{"A", "2"} {
+		m := ACARSMessage{StationID: "STN", FrequencyMHz: 131.55, SignaldBm: -20, AircraftTailCode: ".N12345", Label: "H1", BlockID: blockID, MessageText: "POS N4100.0W07400.0"}
+		got := ReceptionAnnotator{}.AnnotateACARSMessage(m)
+		if wantRecorded := blockID == "2"; (got != nil) != wantRecorded {
+			t.Errorf("ACARS block ID %s: got %v", blockID, got)
+		}
+	}
+	for _, sourceType := range []string{"Ground station", "Aircraft"} {
+		var m VDLM2Message
+		m.VDL2.Station = "STN"
+		m.VDL2.FrequencyHz = 136975000
+		m.VDL2.AVLC.Source.Type = sourceType
+		m.VDL2.AVLC.ACARS.Registration = ".N54321"
+		m.VDL2.AVLC.ACARS.Label = "H1"
+		m.VDL2.AVLC.ACARS.MessageText = "POS N4000.0W07300.0"
+		got := ReceptionAnnotator{}.AnnotateVDLM2Message(m)
+		if wantRecorded := sourceType == "Aircraft"; (got != nil) != wantRecorded {
+			t.Errorf("VDLM2 from %s: got %v", sourceType, got)
+		}
+	}
+	recorded := 0
+	for _, source := range receptionStats.Snapshot() {
+		recorded += len(source.Recent)
+	}
+	if recorded != 2 {
+		t.Errorf("got %d recorded positions, want 2", recorded)
+	}
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultTar1090PollIntervalSeconds = 5
+	defaultTar1090MaxDataAgeSeconds   = 30
+	// On demand fetches back off from this after a failure, doubling each
+	// time up to the max
+	tar1090RetryBackoff    = time.Second
+	tar1090MaxRetryBackoff = time.Minute
+)
+
+// Keeps the latest aircraft.json in memory, indexed so lookups don't need a
+// request or a scan
+type Tar1090Cache struct {
+	mu      sync.RWMutex
+	data    Tar1090AircraftJSON
+	fetched time.Time
+	// When tar1090's "now" last moved on, by our clock, so a readsb that has
+	// stopped writing aircraft.json shows up as stale without comparing
+	// clocks across hosts
+	upstreamUpdated time.Time
+	maxAge          time.Duration
+	byRegistration  map[string]TJSONAircraft
+	byHex           map[string]TJSONAircraft
+	byCallsign      map[string]TJSONAircraft
+	// Only one on demand fetch runs at a time, and not again until
+	// retryAfter if the last one failed
+	refreshing sync.Mutex
+	retryAfter time.Time
+	backoff    time.Duration
+}
+
+var tar1090Cache = &Tar1090Cache{maxAge: defaultTar1090MaxDataAgeSeconds * time.Second}
+
+// Starts polling tar1090 in the background
+func ConfigureTar1090Cache() {
+	if config.TAR1090URL == "" {
+		return
+	}
+	maxAge := config.TAR1090MaxDataAgeSeconds
+	if maxAge == 0 {
+		maxAge = defaultTar1090MaxDataAgeSeconds
+	}
+	tar1090Cache.maxAge = time.Duration(maxAge) * time.Second
+	interval := config.TAR1090PollIntervalSeconds
+	if interval == 0 {
+		interval = defaultTar1090PollIntervalSeconds
+	}
+	// -1 turns off polling so every lookup fetches on demand once the data
+	// is too old
+	if interval > 0 {
+		go tar1090Cache.PollEvery(time.Duration(interval) * time.Second)
+	}
+}
+
+func (c *Tar1090Cache) PollEvery(interval time.Duration) {
+	for {
+		if err := c.Refresh(); err != nil {
+			log.Warnf("error refreshing tar1090 aircraft: %s", err)
+		}
+		time.Sleep(interval)
+	}
+}
+
+// Downloads aircraft.json and rebuilds the indexes
+func (c *Tar1090Cache) Refresh() error {
+	tjson, err := FetchTar1090AircraftJSON()
+	if err != nil {
+		return err
+	}
+	byRegistration := make(map[string]TJSONAircraft, len(tjson.Aircraft))
+	byHex := make(map[string]TJSONAircraft, len(tjson.Aircraft))
+	byCallsign := make(map[string]TJSONAircraft, len(tjson.Aircraft))
+	for _, aircraft := range tjson.Aircraft {
+		if aircraft.Registration != "" {
+			byRegistration[NormalizeAircraftRegistration(aircraft.Registration)] = aircraft
+		}
+		// Non-ICAO addresses keep their "~" so they can't shadow a real one
+		if aircraft.Hex != "" {
+			byHex[strings.ToLower(aircraft.Hex)] = aircraft
+		}
+		if callsign := strings.ToUpper(strings.TrimSpace(aircraft.AircraftTailCode)); callsign != "" {
+			byCallsign[callsign] = aircraft
+		}
+		trackHistory.RecordAircraft(aircraft)
+	}
+	c.mu.Lock()
+	fetched := time.Now()
+	if tjson.Now == 0 || tjson.Now != c.data.Now || c.upstreamUpdated.IsZero() {
+		c.upstreamUpdated = fetched
+	}
+	c.data, c.fetched = tjson, fetched
+	c.byRegistration, c.byHex, c.byCallsign = byRegistration, byHex, byCallsign
+	c.mu.Unlock()
+	log.Debugf("refreshed tar1090 cache with %d aircraft", len(tjson.Aircraft))
+	return nil
+}
+
+// How long since tar1090 last updated aircraft.json, however recently we
+// fetched it
+func (c *Tar1090Cache) DataAge() (age time.Duration, ok bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.upstreamUpdated.IsZero() {
+		return 0, false
+	}
+	return time.Since(c.upstreamUpdated), true
+}
+
+// Whether to fetch again, which only depends on when we last did. Fetching
+// more often can't help if tar1090 itself is behind.
+func (c *Tar1090Cache) fresh() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return !c.fetched.IsZero() && time.Since(c.fetched) <= c.maxAge
+}
+
+// Fetches on demand if the poller has fallen behind. Lookups that arrive
+// while a fetch is running, or while backing off after a failed one, use
+// what's already cached.
+func (c *Tar1090Cache) ensureFresh() {
+	if c.fresh() {
+		return
+	}
+	if !c.refreshing.TryLock() {
+		return
+	}
+	defer c.refreshing.Unlock()
+	// Another lookup may have just refreshed it
+	if c.fresh() || time.Now().Before(c.retryAfter) {
+		return
+	}
+	log.Debug("tar1090 cache is stale, fetching on demand")
+	if err := c.Refresh(); err != nil {
+		c.backoff = min(max(c.backoff*2, tar1090RetryBackoff), tar1090MaxRetryBackoff)
+		c.retryAfter = time.Now().Add(c.backoff)
+		log.Warnf("error refreshing stale tar1090 aircraft, retrying in %s: %s", c.backoff, err)
+		return
+	}
+	c.backoff, c.retryAfter = 0, time.Time{}
+}
+
+func (c *Tar1090Cache) lookup(index func() map[string]TJSONAircraft, key string) (TJSONAircraft, bool) {
+	c.ensureFresh()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	aircraft, ok := index()[key]
+	if !ok {
+		return aircraft, false
+	}
+	// seen and seen_pos count back from tar1090's "now", so they're brought
+	// up to the present like the stream's are
+	behind := time.Since(c.upstreamUpdated).Seconds()
+	aircraft.Seen += behind
+	if aircraft.Latitude != 0 || aircraft.Longitude != 0 {
+		aircraft.SeenPosition += behind
+	}
+	return aircraft, true
+}
+
+func (c *Tar1090Cache) LookupRegistration(reg string) (TJSONAircraft, bool) {
+	return c.lookup(func() map[string]TJSONAircraft { return c.byRegistration }, NormalizeAircraftRegistration(reg))
+}
+
+func (c *Tar1090Cache) LookupHex(hex string) (TJSONAircraft, bool) {
+	return c.lookup(func() map[string]TJSONAircraft { return c.byHex }, strings.ToLower(strings.TrimSpace(hex)))
+}
+
+func (c *Tar1090Cache) LookupCallsign(callsign string) (TJSONAircraft, bool) {
+	return c.lookup(func() map[string]TJSONAircraft { return c.byCallsign }, strings.ToUpper(strings.TrimSpace(callsign)))
+}
+
+// Downloads the full aircraft list from tar1090
+func FetchTar1090AircraftJSON() (tjson Tar1090AircraftJSON, err error) {
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/data/aircraft.json?_=%d", config.TAR1090URL, time.Now().Unix()), nil)
+	if err != nil {
+		return tjson, err
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	log.Debug("making call to tar1090")
+	resp, err := client.Do(req)
+	if err != nil {
+		return tjson, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return tjson, errors.New("tar1090 returned " + resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return tjson, err
+	}
+	err = json.Unmarshal(body, &tjson)
+	return tjson, err
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func serveTar1090(t *testing.T, handler http.HandlerFunc) {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	previous := config.TAR1090URL
+	config.TAR1090URL = server.URL
+	t.Cleanup(func() { config.TAR1090URL = previous })
+}
+
+func TestTar1090CacheIndexes(t *testing.T) {
+	serveTar1090(t, func(w http.ResponseWriter, r *http.Request) {
+		// An hour old by tar1090's clock, which shouldn't matter
+		w.Write([]byte(`{"now": ` + strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10) + `, "aircraft": [
+			{"hex": "a1b2c3", "r": "N12345", "flight": "UAL123  "},
+			{"hex": "~a1b2c4"}
+		]}`))
+	})
+	cache := &Tar1090Cache{maxAge: time.Minute}
+	if err := cache.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	if age, ok := cache.DataAge(); !ok || age > time.Second {
+		t.Errorf("data age %s should count from when it was first fetched", age)
+	}
+	if _, ok := cache.LookupHex("A1B2C3"); !ok {
+		t.Error("hex not found")
+	}
+	if _, ok := cache.LookupRegistration("N-12345"); !ok {
+		t.Error("registration not found")
+	}
+	if _, ok := cache.LookupCallsign("ual123"); !ok {
+		t.Error("callsign not found")
+	}
+	if _, ok := cache.LookupHex("a1b2c4"); ok {
+		t.Error("non-ICAO address matched an ICAO lookup")
+	}
+	if _, ok := cache.LookupHex("~a1b2c4"); !ok {
+		t.Error("non-ICAO address not found")
+	}
+}
+
+func TestTar1090CacheDataAge(t *testing.T) {
+	now := 1700000000.0
+	serveTar1090(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"now": ` + strconv.FormatFloat(now, 'f', 1, 64) + `, "aircraft": [
+			{"hex": "a1b2c3", "lat": 40.5, "lon": -74.0, "seen": 1, "seen_pos": 2}
+		]}`))
+	})
+	cache := &Tar1090Cache{maxAge: time.Minute}
+	if err := cache.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+
+	// tar1090 hasn't updated since a minute ago, however often it's fetched
+	cache.upstreamUpdated = time.Now().Add(-time.Minute)
+	if err := cache.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	if age, _ := cache.DataAge(); age < time.Minute {
+		t.Errorf("data age %s should include the minute tar1090 hasn't updated", age)
+	}
+	aircraft, _ := cache.LookupHex("a1b2c3")
+	if aircraft.SeenPosition < 62 || aircraft.SeenPosition > 63 {
+		t.Errorf("seen_pos %g should be brought up to the present", aircraft.SeenPosition)
+	}
+	previous := aircraftSource
+	aircraftSource = cache
+	t.Cleanup(func() { aircraftSource = previous })
+	if age, ok := Tar1090DataAge(aircraft); !ok || age != aircraft.SeenPosition {
+		t.Errorf("got data age %g, want the position's age", age)
+	}
+
+	now++
+	if err := cache.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	if age, _ := cache.DataAge(); age > time.Second {
+		t.Errorf("data age %s should reset once tar1090 updates", age)
+	}
+}
+
+func TestTar1090CacheBacksOff(t *testing.T) {
+	var requests atomic.Int32
+	serveTar1090(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	cache := &Tar1090Cache{maxAge: time.Minute}
+	for i := 0; i < 5; i++ {
+		cache.LookupHex("a1b2c3")
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("got %d requests, want 1 while backing off", got)
+	}
+	if cache.backoff != tar1090RetryBackoff {
+		t.Errorf("backoff is %s, want %s", cache.backoff, tar1090RetryBackoff)
+	}
+
+	cache.retryAfter = time.Time{}
+	cache.LookupHex("a1b2c3")
+	if got := requests.Load(); got != 2 {
+		t.Errorf("got %d requests, want 2 after the backoff", got)
+	}
+	if cache.backoff != 2*tar1090RetryBackoff {
+		t.Errorf("backoff is %s, want it doubled", cache.backoff)
+	}
+}
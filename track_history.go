@@ -0,0 +1,297 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+type TrackPoint struct {
+	Time         time.Time
+	Latitude     float64
+	Longitude    float64
+	AltitudeFeet int64
+	HasAltitude  bool
+	OnGround     bool
+	// Whether the source says if the aircraft is on the ground. Positions
+	// from message text don't, so OnGround being false means nothing.
+	ReportsGround bool
+	// Zero when the source doesn't give it
+	GroundSpeedKnots float64
+}
+
+type aircraftTrack struct {
+	points  []TrackPoint
+	takeoff time.Time
+	// The last ground state from a source that reports it
+	onGround bool
+}
+
+// Recent positions for each aircraft, keyed by ICAO hex where it's known and
+// by registration otherwise
+type TrackHistory struct {
+	mu        sync.Mutex
+	tracks    map[string]*aircraftTrack
+	maxPoints int
+	maxAge    time.Duration
+}
+
+var trackHistory *TrackHistory
+
+const (
+	defaultTrackHistoryPoints = 60
+	defaultTrackHistoryMaxAge = 1800
+	trackMinimumInterval      = 10 * time.Second
+	// How often to sweep for aircraft that have gone away
+	trackPruneInterval     = time.Minute
+	trackPolylinePrecision = 1e5
+)
+
+// Thresholds for guessing the phase of flight
+const (
+	trackLevelFeetPerMinute  = 300
+	trackApproachFeet        = 4000
+	trackTakeoffWindow       = 3 * time.Minute
+	trackTaxiKnots           = 3
+	trackRunwayKnots         = 50
+	trackVerticalTrendWindow = 2 * time.Minute
+)
+
+const (
+	VerticalTrendClimbing   = "climbing"
+	VerticalTrendDescending = "descending"
+	VerticalTrendLevel      = "level"
+)
+
+const (
+	FlightPhaseTaxi     = "taxi"
+	FlightPhaseTakeoff  = "takeoff"
+	FlightPhaseClimb    = "climb"
+	FlightPhaseCruise   = "cruise"
+	FlightPhaseDescent  = "descent"
+	FlightPhaseApproach = "approach"
+	FlightPhaseLanded   = "landed"
+)
+
+func ConfigureTrackHistory() {
+	if !config.AnnotateTrack {
+		return
+	}
+	points := config.TrackHistoryPoints
+	if points <= 0 {
+		points = defaultTrackHistoryPoints
+	}
+	maxAge := config.TrackHistoryMaxAgeSeconds
+	if maxAge <= 0 {
+		maxAge = defaultTrackHistoryMaxAge
+	}
+	trackHistory = NewTrackHistory(points, time.Duration(maxAge)*time.Second)
+	go trackHistory.PruneEvery(trackPruneInterval)
+}
+
+func NewTrackHistory(maxPoints int, maxAge time.Duration) *TrackHistory {
+	return &TrackHistory{tracks: map[string]*aircraftTrack{}, maxPoints: maxPoints, maxAge: maxAge}
+}
+
+// The key an aircraft's track is stored under. Non-ICAO addresses keep
+// their "~" so they don't share a track with a real one.
+func TrackKey(hex, registration string) string {
+	if hex = strings.ToLower(strings.TrimSpace(hex)); hex != "" {
+		return hex
+	}
+	if registration = NormalizeAircraftRegistration(registration); registration != "" {
+		return "reg:" + registration
+	}
+	return ""
+}
+
+// Adds the current position of an aircraft from tar1090 or the stream
+func (h *TrackHistory) RecordAircraft(aircraft TJSONAircraft) {
+	if aircraft.Latitude == 0 && aircraft.Longitude == 0 {
+		return
+	}
+	h.Record(TrackKey(aircraft.Hex, aircraft.Registration), TrackPoint{
+		Time:             time.Now().Add(-time.Duration(aircraft.SeenPosition * float64(time.Second))),
+		Latitude:         aircraft.Latitude,
+		Longitude:        aircraft.Longitude,
+		AltitudeFeet:     aircraft.AltimeterBarometer.Feet,
+		HasAltitude:      aircraft.AltimeterBarometer.Known && !aircraft.AltimeterBarometer.OnGround,
+		OnGround:         aircraft.AltimeterBarometer.OnGround,
+		ReportsGround:    aircraft.AltimeterBarometer.Known,
+		GroundSpeedKnots: aircraft.GroundSpeedKnots,
+	})
+}
+
+// Adds a point, skipping it if it's too soon after the last one
+func (h *TrackHistory) Record(key string, point TrackPoint) {
+	if h == nil || key == "" {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	track, ok := h.tracks[key]
+	if !ok {
+		track = &aircraftTrack{}
+		h.tracks[key] = track
+	}
+	if n := len(track.points); n > 0 && point.Time.Sub(track.points[n-1].Time) < trackMinimumInterval {
+		return
+	}
+	if point.ReportsGround {
+		if track.onGround && !point.OnGround {
+			track.takeoff = point.Time
+		}
+		track.onGround = point.OnGround
+	}
+	track.points = append(track.points, point)
+	cutoff := point.Time.Add(-h.maxAge)
+	for len(track.points) > 0 && (len(track.points) > h.maxPoints || track.points[0].Time.Before(cutoff)) {
+		track.points = track.points[1:]
+	}
+}
+
+func (h *TrackHistory) PruneEvery(interval time.Duration) {
+	for range time.Tick(interval) {
+		h.Prune()
+	}
+}
+
+// Drops aircraft that haven't been seen in a while
+func (h *TrackHistory) Prune() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	cutoff := time.Now().Add(-h.maxAge)
+	for key, track := range h.tracks {
+		if len(track.points) == 0 || track.points[len(track.points)-1].Time.Before(cutoff) {
+			delete(h.tracks, key)
+		}
+	}
+}
+
+// Returns a copy of the track and when the aircraft took off, if we saw it
+func (h *TrackHistory) Track(key string) (points []TrackPoint, takeoff time.Time) {
+	if h == nil {
+		return nil, takeoff
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	track, ok := h.tracks[key]
+	if !ok {
+		return nil, takeoff
+	}
+	return append([]TrackPoint(nil), track.points...), track.takeoff
+}
+
+// Feet per minute over the last couple of minutes of the track, from the
+// points that have an altitude
+func TrackVerticalRate(points []TrackPoint) (feetPerMinute float64, ok bool) {
+	withAltitude := make([]TrackPoint, 0, len(points))
+	for _, point := range points {
+		if point.HasAltitude {
+			withAltitude = append(withAltitude, point)
+		}
+	}
+	if len(withAltitude) < 2 {
+		return 0, false
+	}
+	last := withAltitude[len(withAltitude)-1]
+	first := withAltitude[len(withAltitude)-2]
+	for i := len(withAltitude) - 2; i >= 0; i-- {
+		if last.Time.Sub(withAltitude[i].Time) > trackVerticalTrendWindow {
+			break
+		}
+		first = withAltitude[i]
+	}
+	minutes := last.Time.Sub(first.Time).Minutes()
+	if minutes <= 0 {
+		return 0, false
+	}
+	return float64(last.AltitudeFeet-first.AltitudeFeet) / minutes, true
+}
+
+func VerticalTrend(feetPerMinute float64) string {
+	switch {
+	case feetPerMinute > trackLevelFeetPerMinute:
+		return VerticalTrendClimbing
+	case feetPerMinute < -trackLevelFeetPerMinute:
+		return VerticalTrendDescending
+	}
+	return VerticalTrendLevel
+}
+
+// Guesses the phase of flight from the most recent points, or returns ""
+// if there aren't enough altitudes to tell
+func FlightPhase(points []TrackPoint, takeoff time.Time) string {
+	if len(points) == 0 {
+		return ""
+	}
+	last := points[len(points)-1]
+	if last.OnGround {
+		switch {
+		case last.GroundSpeedKnots >= trackRunwayKnots && len(points) > 1 && last.GroundSpeedKnots > points[len(points)-2].GroundSpeedKnots:
+			return FlightPhaseTakeoff
+		case last.GroundSpeedKnots > trackTaxiKnots && last.GroundSpeedKnots < trackRunwayKnots:
+			return FlightPhaseTaxi
+		}
+		return FlightPhaseLanded
+	}
+	// Positions alone don't say what phase the aircraft is in
+	rate, ok := TrackVerticalRate(points)
+	if !ok {
+		return ""
+	}
+	switch VerticalTrend(rate) {
+	case VerticalTrendClimbing:
+		if !takeoff.IsZero() && last.Time.Sub(takeoff) < trackTakeoffWindow {
+			return FlightPhaseTakeoff
+		}
+		return FlightPhaseClimb
+	case VerticalTrendDescending:
+		for i := len(points) - 1; i >= 0; i-- {
+			if points[i].HasAltitude {
+				if points[i].AltitudeFeet < trackApproachFeet {
+					return FlightPhaseApproach
+				}
+				break
+			}
+		}
+		return FlightPhaseDescent
+	}
+	return FlightPhaseCruise
+}
+
+// Encodes the track with Google's polyline algorithm, which most map
+// libraries can draw
+func EncodePolyline(points []TrackPoint) string {
+	var b strings.Builder
+	var lastLat, lastLon int64
+	encode := func(value int64) {
+		value <<= 1
+		if value < 0 {
+			value = ^value
+		}
+		for value >= 0x20 {
+			b.WriteByte(byte((0x20 | (value & 0x1f)) + 63))
+			value >>= 5
+		}
+		b.WriteByte(byte(value + 63))
+	}
+	for _, point := range points {
+		lat := int64(math.Round(point.Latitude * trackPolylinePrecision))
+		lon := int64(math.Round(point.Longitude * trackPolylinePrecision))
+		encode(lat - lastLat)
+		encode(lon - lastLon)
+		lastLat, lastLon = lat, lon
+	}
+	return b.String()
+}
+
+// [longitude, latitude] pairs, as GeoJSON expects
+func TrackCoordinates(points []TrackPoint) [][]float64 {
+	coordinates := make([][]float64, 0, len(points))
+	for _, point := range points {
+		coordinates = append(coordinates, []float64{point.Longitude, point.Latitude})
+	}
+	return coordinates
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+var trackStart = time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+// A point from a source with altitude and ground state, minutes after start
+func airbornePoint(minutes float64, feet int64) TrackPoint {
+	return TrackPoint{
+		Time:          trackStart.Add(time.Duration(minutes * float64(time.Minute))),
+		Latitude:      40,
+		Longitude:     -74,
+		AltitudeFeet:  feet,
+		HasAltitude:   true,
+		ReportsGround: true,
+	}
+}
+
+func groundPoint(minutes float64, knots float64) TrackPoint {
+	return TrackPoint{
+		Time:             trackStart.Add(time.Duration(minutes * float64(time.Minute))),
+		OnGround:         true,
+		ReportsGround:    true,
+		GroundSpeedKnots: knots,
+	}
+}
+
+// A position from message text, with no altitude or ground state
+func messagePoint(minutes float64) TrackPoint {
+	return TrackPoint{Time: trackStart.Add(time.Duration(minutes * float64(time.Minute))), Latitude: 40, Longitude: -74}
+}
+
+func TestFlightPhase(t *testing.T) {
+	tests := []struct {
+		name    string
+		points  []TrackPoint
+		takeoff time.Time
+		want    string
+	}{
+		{"no points", nil, time.Time{}, ""},
+		{"taxi", []TrackPoint{groundPoint(0, 0), groundPoint(1, 15)}, time.Time{}, FlightPhaseTaxi},
+		{"takeoff roll", []TrackPoint{groundPoint(0, 40), groundPoint(0.5, 120)}, time.Time{}, FlightPhaseTakeoff},
+		{"landed", []TrackPoint{groundPoint(0, 60), groundPoint(1, 0)}, time.Time{}, FlightPhaseLanded},
+		{"climbing after takeoff", []TrackPoint{airbornePoint(0, 500), airbornePoint(1, 3000)}, trackStart, FlightPhaseTakeoff},
+		{"climb", []TrackPoint{airbornePoint(0, 10000), airbornePoint(1, 12000)}, time.Time{}, FlightPhaseClimb},
+		{"cruise", []TrackPoint{airbornePoint(0, 35000), airbornePoint(1, 35000)}, time.Time{}, FlightPhaseCruise},
+		{"descent", []TrackPoint{airbornePoint(0, 20000), airbornePoint(1, 18000)}, time.Time{}, FlightPhaseDescent},
+		{"approach", []TrackPoint{airbornePoint(0, 4500), airbornePoint(1, 3000)}, time.Time{}, FlightPhaseApproach},
+		{"approach with a message position last", []TrackPoint{airbornePoint(0, 4500), airbornePoint(1, 3000), messagePoint(1.5)}, time.Time{}, FlightPhaseApproach},
+		{"positions only", []TrackPoint{messagePoint(0), messagePoint(1)}, time.Time{}, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := FlightPhase(test.points, test.takeoff); got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestTrackVerticalRateSkipsMissingAltitudes(t *testing.T) {
+	points := []TrackPoint{airbornePoint(0, 30000), messagePoint(0.5), airbornePoint(1, 31000), messagePoint(1.5)}
+	rate, ok := TrackVerticalRate(points)
+	if !ok || rate != 1000 {
+		t.Errorf("got %f %t, want 1000 true", rate, ok)
+	}
+	if _, ok := TrackVerticalRate([]TrackPoint{airbornePoint(0, 30000), messagePoint(1)}); ok {
+		t.Error("expected no rate from a single altitude")
+	}
+}
+
+func TestTrackHistoryTakeoff(t *testing.T) {
+	h := NewTrackHistory(10, time.Hour)
+	h.Record("a1b2c3", groundPoint(0, 0))
+	// A message position isn't evidence the aircraft left the ground
+	h.Record("a1b2c3", messagePoint(1))
+	if _, takeoff := h.Track("a1b2c3"); !takeoff.IsZero() {
+		t.Fatalf("takeoff set from a message position: %s", takeoff)
+	}
+	h.Record("a1b2c3", airbornePoint(2, 1500))
+	if _, takeoff := h.Track("a1b2c3"); !takeoff.Equal(airbornePoint(2, 0).Time) {
+		t.Errorf("takeoff is %s, want %s", takeoff, airbornePoint(2, 0).Time)
+	}
+}
+
+func TestTrackHistoryRecord(t *testing.T) {
+	h := NewTrackHistory(3, time.Hour)
+	h.Record("a1b2c3", airbornePoint(0, 1000))
+	// Too soon after the last one
+	h.Record("a1b2c3", airbornePoint(0.1, 1100))
+	for minutes := 1.0; minutes <= 4; minutes++ {
+		h.Record("a1b2c3", airbornePoint(minutes, 1000))
+	}
+	points, _ := h.Track("a1b2c3")
+	if len(points) != 3 || !points[0].Time.Equal(airbornePoint(2, 0).Time) {
+		t.Errorf("got %d points starting %s, want the last 3", len(points), points[0].Time)
+	}
+
+	h.Record("gone", TrackPoint{Time: time.Now().Add(-2 * time.Hour)})
+	h.Record("here", TrackPoint{Time: time.Now()})
+	h.Prune()
+	if points, _ := h.Track("gone"); points != nil {
+		t.Error("old aircraft wasn't pruned")
+	}
+	if points, _ := h.Track("here"); points == nil {
+		t.Error("recent aircraft was pruned")
+	}
+}
+
+func TestTrackKey(t *testing.T) {
+	tests := []struct{ hex, registration, want string }{
+		{"A1B2C3", "N12345", "a1b2c3"},
+		{"~A1B2C3", "", "~a1b2c3"},
+		{"", "N-12345", "reg:" + NormalizeAircraftRegistration("N12345")},
+		{"", "", ""},
+	}
+	for _, test := range tests {
+		if got := TrackKey(test.hex, test.registration); got != test.want {
+			t.Errorf("%q %q: got %q, want %q", test.hex, test.registration, got, test.want)
+		}
+	}
+}
+
+func TestEncodePolyline(t *testing.T) {
+	// The example from Google's polyline algorithm documentation
+	points := []TrackPoint{
+		{Latitude: 38.5, Longitude: -120.2},
+		{Latitude: 40.7, Longitude: -120.95},
+		{Latitude: 43.252, Longitude: -126.453},
+	}
+	if got, want := EncodePolyline(points), "_p~iF~ps|U_ulLnnqC_mqNvxq`@"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -6,6 +6,14 @@ const (
 	WebhookUserAgent  = "github.com/tyzbit/acars-annotator"
 )
 
+const kmPerNauticalMile = 1.852
+
+// Where an aircraft position came from, for the positionSource field
+const (
+	PositionSourceADSB    = "ADS-B"
+	PositionSourceMessage = "message"
+)
+
 // ALL KEYS MUST BE UNIQUE AMONG ALL ANNOTATORS
 type Annotation map[string]interface{}
 